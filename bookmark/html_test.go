@@ -7,13 +7,15 @@
 package bookmark
 
 import (
-	"encoding/json"
 	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/andrewarchi/browser/internal/golden"
 )
 
 func TestBookmarks(t *testing.T) {
-	f, err := os.Open("Bookmarks.html")
+	f, err := os.Open(filepath.Join("testdata", "Bookmarks.html"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -22,9 +24,5 @@ func TestBookmarks(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	e := json.NewEncoder(os.Stdout)
-	e.SetIndent("", "  ")
-	if err := e.Encode(b); err != nil {
-		t.Error(err)
-	}
+	golden.Check(t, "Bookmarks.html", b)
 }
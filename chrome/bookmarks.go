@@ -29,15 +29,15 @@ type BookmarkRoots struct {
 
 // BookmarkEntry is either a folder containing further entries or a URL.
 type BookmarkEntry struct {
-	Children     []BookmarkEntry      `json:"children"` // for folder type only
-	DateAdded    timefmt.QuotedChrome `json:"date_added"`
-	DateModified timefmt.QuotedChrome `json:"date_modified,omitempty"` // for folder type only
-	GUID         *uuid.UUID           `json:"guid"`                    // "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"
-	ID           string               `json:"id"`                      // e.g. "567"
-	Name         string               `json:"name"`
-	Type         string               `json:"type"` // "folder" or "url"
-	MetaInfo     *BookmarkMetaInfo    `json:"meta_info,omitempty"`
-	URL          string               `json:"url,omitempty"` // for url type only
+	Children     []BookmarkEntry       `json:"children"` // for folder type only
+	DateAdded    timefmt.QuotedChrome  `json:"date_added"`
+	DateModified *timefmt.QuotedChrome `json:"date_modified,omitempty"` // for folder type only
+	GUID         *uuid.UUID            `json:"guid"`                    // "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"
+	ID           string                `json:"id"`                      // e.g. "567"
+	Name         string                `json:"name"`
+	Type         string                `json:"type"` // "folder" or "url"
+	MetaInfo     *BookmarkMetaInfo     `json:"meta_info,omitempty"`
+	URL          string                `json:"url,omitempty"` // for url type only
 }
 
 // BookmarkMetaInfo contains additional bookmark metadata.
@@ -0,0 +1,43 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package chrome
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/andrewarchi/browser/internal/golden"
+	"github.com/andrewarchi/browser/jsonutil"
+)
+
+func TestParseBookmarks(t *testing.T) {
+	bookmarks, err := ParseBookmarks(filepath.Join("testdata", "Bookmarks"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "Bookmarks", bookmarks)
+}
+
+func TestBookmarksRoundTrip(t *testing.T) {
+	filename := filepath.Join("testdata", "Bookmarks")
+	bookmarks, err := ParseBookmarks(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffs, err := jsonutil.Verify(data, bookmarks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diffs {
+		t.Errorf("%s: %s", filename, d)
+	}
+}
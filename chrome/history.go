@@ -0,0 +1,206 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package chrome
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/andrewarchi/browser/jsonutil/timefmt"
+)
+
+// The History database is SQLite. No driver is imported by this
+// package, so callers open the database with a driver of their choice,
+// such as github.com/mattn/go-sqlite3. Chrome holds a lock on the
+// database while running, so a copy should be opened instead, and
+// Chrome must be closed while the database is modified.
+//
+// Schema in Chromium source:
+// https://source.chromium.org/chromium/chromium/src/+/master:components/history/core/browser/visit_database.cc
+// https://source.chromium.org/chromium/chromium/src/+/master:components/history/core/browser/url_database.cc
+
+// HistoryVisit is a row in the visits table of the History database,
+// joined with its row in the urls table.
+type HistoryVisit struct {
+	ID         int64
+	URL        string
+	Title      string
+	VisitTime  time.Time // UTC
+	FromVisit  int64     // ID of referring visit or 0
+	Transition PageTransition
+	Duration   time.Duration
+}
+
+// ScanHistory calls fn for each visit in the History database, ordered
+// by visit time.
+func ScanHistory(db *sql.DB, fn func(v *HistoryVisit) error) error {
+	rows, err := db.Query(`SELECT visits.id, urls.url, urls.title,
+		visits.visit_time, visits.from_visit, visits.transition, visits.visit_duration
+		FROM visits JOIN urls ON visits.url = urls.id
+		ORDER BY visits.visit_time, visits.id`)
+	if err != nil {
+		return fmt.Errorf("chrome: query history: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			v                   HistoryVisit
+			title               sql.NullString
+			visitTime, duration int64
+			fromVisit           sql.NullInt64
+			transition          int64
+		)
+		if err := rows.Scan(&v.ID, &v.URL, &title, &visitTime, &fromVisit, &transition, &duration); err != nil {
+			return fmt.Errorf("chrome: scan history: %w", err)
+		}
+		if visitTime < 0 {
+			return fmt.Errorf("chrome: visit %d has negative time: %d", v.ID, visitTime)
+		}
+		v.Title = title.String
+		v.VisitTime = FromHistoryTime(visitTime)
+		v.FromVisit = fromVisit.Int64
+		// Transitions are stored as signed 32-bit integers.
+		v.Transition = PageTransition(uint32(transition))
+		v.Duration = time.Duration(duration) * time.Microsecond
+		if err := fn(&v); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// HistoryWriter appends visits to the History database within a single
+// transaction. The visit_count, typed_count, last_visit_time, and
+// hidden columns of urls are updated as Chrome would when adding each
+// visit. Visits that are already present, by URL and visit time, are
+// skipped, so importing the same history again does not create
+// duplicates.
+type HistoryWriter struct {
+	tx *sql.Tx
+
+	Inserted   int // visits inserted
+	Duplicates int // visits skipped, because they were already present
+}
+
+// NewHistoryWriter begins a transaction for appending visits to db.
+func NewHistoryWriter(db *sql.DB) (*HistoryWriter, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("chrome: begin history transaction: %w", err)
+	}
+	return &HistoryWriter{tx: tx}, nil
+}
+
+// Add appends a visit. Visit times are truncated to microseconds.
+func (w *HistoryWriter) Add(url, title string, visitTime time.Time, transition PageTransition) error {
+	t := ToHistoryTime(visitTime)
+	id, err := w.url(url, title)
+	if err != nil {
+		return err
+	}
+	var exists bool
+	if err := w.tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM visits
+		WHERE url = ? AND visit_time = ?)`, id, t).Scan(&exists); err != nil {
+		return fmt.Errorf("chrome: query visit: %w", err)
+	}
+	if exists {
+		w.Duplicates++
+		return nil
+	}
+	// Transitions are stored as signed 32-bit integers.
+	if _, err := w.tx.Exec(`INSERT INTO visits
+		(url, visit_time, from_visit, transition, segment_id, visit_duration)
+		VALUES (?, ?, 0, ?, 0, 0)`, id, t, int64(int32(transition))); err != nil {
+		return fmt.Errorf("chrome: insert visit: %w", err)
+	}
+
+	// Mirror HistoryBackend::AddPageVisit in Chromium: subframe
+	// navigations are hidden, but a URL is never hidden once visited in
+	// the main frame.
+	typed := 0
+	if transition.isTypedIncrement() {
+		typed = 1
+	}
+	hidden := 0
+	if core := transition.Core(); core == TransitionAutoSubframe || core == TransitionManualSubframe {
+		hidden = 1
+	}
+	if _, err := w.tx.Exec(`UPDATE urls SET
+		visit_count = visit_count + 1,
+		typed_count = typed_count + ?,
+		last_visit_time = MAX(last_visit_time, ?),
+		hidden = MIN(hidden, ?)
+		WHERE id = ?`, typed, t, hidden, id); err != nil {
+		return fmt.Errorf("chrome: update url: %w", err)
+	}
+	w.Inserted++
+	return nil
+}
+
+// isTypedIncrement reports whether a visit with the transition
+// increments the typed count of its URL, as in IsTypedIncrement in
+// Chromium.
+func (typ PageTransition) isTypedIncrement() bool {
+	if typ.Has(TransitionForwardBack) || typ.Core() == TransitionReload {
+		return false
+	}
+	return typ.Core() == TransitionTyped && !typ.IsRedirect() ||
+		typ.Core() == TransitionKeywordGenerated
+}
+
+// url returns the ID of the row in urls for the URL, inserting it, if
+// not present. The title of an existing row is set, if it has none.
+func (w *HistoryWriter) url(url, title string) (int64, error) {
+	var id int64
+	var oldTitle sql.NullString
+	err := w.tx.QueryRow(`SELECT id, title FROM urls WHERE url = ?`, url).Scan(&id, &oldTitle)
+	if err == nil {
+		if oldTitle.String == "" && title != "" {
+			if _, err := w.tx.Exec(`UPDATE urls SET title = ? WHERE id = ?`, title, id); err != nil {
+				return 0, fmt.Errorf("chrome: update url title: %w", err)
+			}
+		}
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("chrome: query url: %w", err)
+	}
+	res, err := w.tx.Exec(`INSERT INTO urls
+		(url, title, visit_count, typed_count, last_visit_time, hidden)
+		VALUES (?, ?, 0, 0, 0, 1)`, url, title)
+	if err != nil {
+		return 0, fmt.Errorf("chrome: insert url: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// Commit commits the appended visits.
+func (w *HistoryWriter) Commit() error {
+	if err := w.tx.Commit(); err != nil {
+		return fmt.Errorf("chrome: commit history transaction: %w", err)
+	}
+	return nil
+}
+
+// Rollback discards the appended visits.
+func (w *HistoryWriter) Rollback() error {
+	return w.tx.Rollback()
+}
+
+// FromHistoryTime converts a time in the History database, which is in
+// microseconds since the Windows epoch, to UTC.
+func FromHistoryTime(t int64) time.Time {
+	return timefmt.FromInt(t, 0, timefmt.Micro, timefmt.Windows)
+}
+
+// ToHistoryTime converts a time to microseconds since the Windows
+// epoch, for the History database.
+func ToHistoryTime(t time.Time) int64 {
+	n, _ := timefmt.ToInt(t, timefmt.Micro, timefmt.Windows)
+	return n
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package chrome
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// historySchema is the subset of the History database schema used by
+// this package.
+const historySchema = `
+CREATE TABLE urls(id INTEGER PRIMARY KEY AUTOINCREMENT,url LONGVARCHAR,title LONGVARCHAR,visit_count INTEGER DEFAULT 0 NOT NULL,typed_count INTEGER DEFAULT 0 NOT NULL,last_visit_time INTEGER NOT NULL,hidden INTEGER DEFAULT 0 NOT NULL);
+CREATE TABLE visits(id INTEGER PRIMARY KEY,url INTEGER NOT NULL,visit_time INTEGER NOT NULL,from_visit INTEGER,transition INTEGER DEFAULT 0 NOT NULL,segment_id INTEGER,visit_duration INTEGER DEFAULT 0 NOT NULL,incremented_omnibox_typed_score BOOLEAN DEFAULT FALSE NOT NULL);
+CREATE INDEX visits_url_index ON visits (url);
+CREATE INDEX visits_time_index ON visits (visit_time);
+CREATE INDEX urls_url_index ON urls (url);
+`
+
+// openTestHistory creates a History database with the given statements
+// executed after the schema.
+func openTestHistory(t *testing.T, stmts ...string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "History"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, stmt := range append([]string{historySchema}, stmts...) {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestScanHistory(t *testing.T) {
+	db := openTestHistory(t,
+		`INSERT INTO urls VALUES (1, 'https://example.com/', 'Example Domain', 2, 1, 13255920000654321, 0)`,
+		`INSERT INTO urls VALUES (2, 'https://example.org/article', NULL, 1, 0, 13256697600000001, 0)`,
+		`INSERT INTO visits VALUES (1, 1, 13255920000654321, 0, 805306369, 0, 1500000, 0)`,
+		`INSERT INTO visits VALUES (2, 2, 13256697600000001, 1, -2147483648, 0, 0, 0)`,
+		`INSERT INTO visits VALUES (3, 1, 13256697600000000, NULL, 8, 0, 0, 0)`)
+
+	var got []HistoryVisit
+	err := ScanHistory(db, func(v *HistoryVisit) error {
+		got = append(got, *v)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []HistoryVisit{
+		{1, "https://example.com/", "Example Domain", time.Date(2021, 1, 24, 0, 0, 0, 654321000, time.UTC),
+			0, TransitionTyped | TransitionChainStart | TransitionChainEnd, 1500 * time.Millisecond},
+		{3, "https://example.com/", "Example Domain", time.Date(2021, 2, 2, 0, 0, 0, 0, time.UTC),
+			0, TransitionReload, 0},
+		{2, "https://example.org/article", "", time.Date(2021, 2, 2, 0, 0, 0, 1000, time.UTC),
+			1, TransitionLink | TransitionServerRedirect, 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestHistoryWriter(t *testing.T) {
+	db := openTestHistory(t,
+		`INSERT INTO urls VALUES (1, 'https://example.com/', NULL, 1, 0, 13255920000654321, 0)`,
+		`INSERT INTO visits VALUES (1, 1, 13255920000654321, 0, 0, 0, 0, 0)`)
+	visits := []struct {
+		URL, Title string
+		Time       time.Time
+		Transition PageTransition
+	}{
+		{"https://example.com/", "Example Domain", time.Date(2021, 1, 24, 0, 0, 0, 654321000, time.UTC), TransitionLink},
+		{"https://example.com/", "", time.Date(2021, 2, 2, 0, 0, 0, 123456789, time.UTC),
+			TransitionTyped | TransitionFromAddressBar | TransitionChainStart | TransitionChainEnd},
+		{"https://example.com/", "", time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), TransitionTyped | TransitionForwardBack},
+		{"https://example.org/frame", "Frame", time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), TransitionManualSubframe},
+		{"https://example.net/", "", time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC), TransitionLink | TransitionServerRedirect},
+	}
+
+	// Importing twice must not create duplicates.
+	for i := 0; i < 2; i++ {
+		w, err := NewHistoryWriter(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range visits {
+			if err := w.Add(v.URL, v.Title, v.Time, v.Transition); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		inserted, duplicates := 4, 1
+		if i == 1 {
+			inserted, duplicates = 0, 5
+		}
+		if w.Inserted != inserted || w.Duplicates != duplicates {
+			t.Errorf("import %d: got %d inserted, %d duplicates, want %d, %d",
+				i, w.Inserted, w.Duplicates, inserted, duplicates)
+		}
+	}
+
+	type url struct {
+		URL, Title                        string
+		VisitCount, TypedCount, LastVisit int64
+		Hidden                            bool
+	}
+	rows, err := db.Query(`SELECT url, title, visit_count, typed_count, last_visit_time, hidden FROM urls ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var urls []url
+	for rows.Next() {
+		var u url
+		if err := rows.Scan(&u.URL, &u.Title, &u.VisitCount, &u.TypedCount, &u.LastVisit, &u.Hidden); err != nil {
+			t.Fatal(err)
+		}
+		urls = append(urls, u)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	wantURLs := []url{
+		{"https://example.com/", "Example Domain", 3, 1, 13256697600123456, false},
+		{"https://example.org/frame", "Frame", 1, 0, 13256611200000000, true},
+		{"https://example.net/", "", 1, 0, 13256784000000000, false},
+	}
+	if !reflect.DeepEqual(urls, wantURLs) {
+		t.Errorf("got urls %v, want %v", urls, wantURLs)
+	}
+
+	var got []PageTransition
+	err = ScanHistory(db, func(v *HistoryVisit) error {
+		got = append(got, v.Transition)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PageTransition{
+		TransitionLink,
+		TransitionTyped | TransitionForwardBack,
+		TransitionManualSubframe,
+		TransitionTyped | TransitionFromAddressBar | TransitionChainStart | TransitionChainEnd,
+		TransitionLink | TransitionServerRedirect,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got transitions %v, want %v", got, want)
+	}
+}
@@ -55,12 +55,53 @@ const (
 	TransitionQualifierMask  PageTransition = 0xFFFFFF00
 )
 
-// MarshalText implements the encoding.TextMarshaler i
+// qualifierNames are the names of the qualifiers, in the order that
+// they are formatted.
+var qualifierNames = []struct {
+	Qualifier PageTransition
+	Name      string
+}{
+	{TransitionFromAPI3, "from_api_3"},
+	{TransitionFromAPI2, "from_api_2"},
+	{TransitionBlocked, "blocked"},
+	{TransitionForwardBack, "forward_back"},
+	{TransitionFromAddressBar, "from_address_bar"},
+	{TransitionHomePage, "home_page"},
+	{TransitionFromAPI, "from_api"},
+	{TransitionChainStart, "chain_start"},
+	{TransitionChainEnd, "chain_end"},
+	{TransitionClientRedirect, "client_redirect"},
+	{TransitionServerRedirect, "server_redirect"},
+}
+
+// Core returns the core value of the transition, without qualifiers.
+func (typ PageTransition) Core() PageTransition {
+	return typ & TransitionCoreMask
+}
+
+// Qualifiers returns the qualifiers of the transition, without the
+// core value.
+func (typ PageTransition) Qualifiers() PageTransition {
+	return typ & TransitionQualifierMask
+}
+
+// Has reports whether the transition has all of the given qualifiers.
+func (typ PageTransition) Has(qualifiers PageTransition) bool {
+	return typ&qualifiers == qualifiers
+}
+
+// IsRedirect reports whether the transition is a client or server
+// redirect.
+func (typ PageTransition) IsRedirect() bool {
+	return typ&TransitionIsRedirectMask != 0
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
 func (typ PageTransition) MarshalText() ([]byte, error) {
 	return []byte(typ.String()), nil
 }
 
-// UnmarshalText implements the encoding.TextUnmarshaler
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
 func (typ *PageTransition) UnmarshalText(data []byte) error {
 	t, err := PageTransitionFromString(string(data))
 	if err != nil {
@@ -80,10 +121,31 @@ func (typ *PageTransition) UnmarshalJSON(data []byte) error {
 	return jsonutil.QuotedUnmarshal(data, typ)
 }
 
-// PageTransitionFromString returns the page transition core value
-// corresponding to the string.
+// PageTransitionFromString parses a page transition in the form
+// formatted by String: a core value followed by zero or more
+// qualifiers, separated by "|", as in "link|forward_back|chain_end".
+// Names are case-insensitive.
 func PageTransitionFromString(typ string) (PageTransition, error) {
-	switch strings.ToLower(typ) {
+	parts := strings.Split(typ, "|")
+	t, err := parseCore(parts[0])
+	if err != nil {
+		return 0, err
+	}
+	for _, part := range parts[1:] {
+		q, err := parseQualifier(part)
+		if err != nil {
+			return 0, err
+		}
+		if t&q != 0 {
+			return 0, fmt.Errorf("chrome: duplicate transition qualifier in %q", typ)
+		}
+		t |= q
+	}
+	return t, nil
+}
+
+func parseCore(typ string) (PageTransition, error) {
+	switch typ = strings.ToLower(typ); typ {
 	case "link":
 		return TransitionLink, nil
 	case "typed":
@@ -106,13 +168,64 @@ func PageTransitionFromString(typ string) (PageTransition, error) {
 		return TransitionKeyword, nil
 	case "keyword_generated":
 		return TransitionKeywordGenerated, nil
-	default:
-		return 0, fmt.Errorf("chrome: unrecognized transition type: %q", typ)
 	}
+	if n, ok := parseCall(typ, "transition_type"); ok {
+		if core, err := strconv.ParseUint(n, 10, 8); err == nil {
+			return PageTransition(core), nil
+		}
+	}
+	return 0, fmt.Errorf("chrome: unrecognized transition type: %q", typ)
+}
+
+func parseQualifier(qualifier string) (PageTransition, error) {
+	qualifier = strings.ToLower(qualifier)
+	for _, q := range qualifierNames {
+		if q.Name == qualifier {
+			return q.Qualifier, nil
+		}
+	}
+	if n, ok := parseCall(qualifier, "qualifiers"); ok && strings.HasPrefix(n, "0x") {
+		q, err := strconv.ParseUint(n[2:], 16, 32)
+		if err == nil && q != 0 && PageTransition(q)&^TransitionQualifierMask == 0 {
+			return PageTransition(q), nil
+		}
+	}
+	return 0, fmt.Errorf("chrome: unrecognized transition qualifier: %q", qualifier)
 }
 
+// parseCall extracts the argument from a string of the form
+// "name(arg)".
+func parseCall(s, name string) (string, bool) {
+	if strings.HasPrefix(s, name+"(") && strings.HasSuffix(s, ")") {
+		return s[len(name)+1 : len(s)-1], true
+	}
+	return "", false
+}
+
+// String formats the transition as its core value followed by its
+// qualifiers, separated by "|", as in "link|forward_back|chain_end".
+// Qualifier bits without a name are formatted together in hexadecimal,
+// as in "qualifiers(0x100)", so that every value round-trips through
+// PageTransitionFromString.
 func (typ PageTransition) String() string {
-	switch typ & TransitionCoreMask {
+	var b strings.Builder
+	b.WriteString(typ.Core().coreString())
+	q := typ.Qualifiers()
+	for _, qn := range qualifierNames {
+		if q&qn.Qualifier != 0 {
+			b.WriteByte('|')
+			b.WriteString(qn.Name)
+			q &^= qn.Qualifier
+		}
+	}
+	if q != 0 {
+		fmt.Fprintf(&b, "|qualifiers(0x%x)", uint32(q))
+	}
+	return b.String()
+}
+
+func (typ PageTransition) coreString() string {
+	switch typ {
 	case TransitionLink:
 		return "link"
 	case TransitionTyped:
@@ -0,0 +1,95 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package chrome
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPageTransitionString(t *testing.T) {
+	tests := []struct {
+		Transition PageTransition
+		String     string
+	}{
+		{TransitionLink, "link"},
+		{TransitionTyped | TransitionFromAddressBar, "typed|from_address_bar"},
+		{TransitionLink | TransitionChainEnd | TransitionForwardBack, "link|forward_back|chain_end"},
+		{TransitionFormSubmit | TransitionChainStart | TransitionChainEnd | TransitionServerRedirect,
+			"form_submit|chain_start|chain_end|server_redirect"},
+		{11, "transition_type(11)"},
+		{TransitionReload | 0x100 | 0x00100000 | TransitionClientRedirect,
+			"reload|client_redirect|qualifiers(0x100100)"},
+	}
+	for _, tt := range tests {
+		if s := tt.Transition.String(); s != tt.String {
+			t.Errorf("PageTransition(%#x).String() = %q, want %q", uint32(tt.Transition), s, tt.String)
+		}
+		typ, err := PageTransitionFromString(tt.String)
+		if err != nil {
+			t.Errorf("PageTransitionFromString(%q): %v", tt.String, err)
+		} else if typ != tt.Transition {
+			t.Errorf("PageTransitionFromString(%q) = %#x, want %#x", tt.String, uint32(typ), uint32(tt.Transition))
+		}
+	}
+}
+
+func TestPageTransitionFromStringErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"linked",
+		"link|",
+		"link|typed",
+		"link|chain_end|chain_end",
+		"transition_type(256)",
+		"link|qualifiers(0xff)",
+		"link|qualifiers(0x0)",
+		"link|qualifiers(256)",
+	} {
+		if typ, err := PageTransitionFromString(s); err == nil {
+			t.Errorf("PageTransitionFromString(%q) = %#x, want error", s, uint32(typ))
+		}
+	}
+}
+
+func TestPageTransitionJSON(t *testing.T) {
+	// Every bit pattern round-trips exactly.
+	for _, typ := range []PageTransition{
+		0, TransitionKeywordGenerated, 0xFF, 0xFFFFFFFF,
+		TransitionTyped | TransitionIsRedirectMask, 0x12345678,
+	} {
+		data, err := json.Marshal(typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got PageTransition
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Errorf("unmarshal %s: %v", data, err)
+		} else if got != typ {
+			t.Errorf("unmarshal %s: got %#x, want %#x", data, uint32(got), uint32(typ))
+		}
+	}
+}
+
+func TestPageTransitionQualifiers(t *testing.T) {
+	typ := TransitionLink | TransitionForwardBack | TransitionServerRedirect
+	if core := typ.Core(); core != TransitionLink {
+		t.Errorf("got core %v, want %v", core, TransitionLink)
+	}
+	if q := typ.Qualifiers(); q != TransitionForwardBack|TransitionServerRedirect {
+		t.Errorf("got qualifiers %#x, want %#x", uint32(q), uint32(TransitionForwardBack|TransitionServerRedirect))
+	}
+	if !typ.IsRedirect() {
+		t.Errorf("%v is redirect", typ)
+	}
+	if TransitionTyped.IsRedirect() {
+		t.Errorf("%v is not redirect", TransitionTyped)
+	}
+	if !typ.Has(TransitionForwardBack) || typ.Has(TransitionForwardBack|TransitionChainEnd) {
+		t.Errorf("%v: incorrect Has", typ)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andrewarchi/browser/bookmark"
+	"github.com/andrewarchi/browser/chrome"
+	"github.com/andrewarchi/browser/firefox"
+)
+
+func runBookmarks(args []string) error {
+	fs := newFlagSet("bookmarks", "file")
+	format := formatFlag(fs, formatJSON)
+	fs.Parse(args)
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+	filename := fs.Arg(0)
+
+	t := &table{header: []string{"Folder", "Title", "URL", "Added"}}
+	base := filepath.Base(filename)
+	switch {
+	case strings.EqualFold(filepath.Ext(base), ".html"), strings.EqualFold(filepath.Ext(base), ".htm"):
+		f, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		entries, err := bookmark.ParseHTML(f)
+		if err != nil {
+			return err
+		}
+		t.value = entries
+		flattenHTML(t, "", entries)
+	case strings.HasPrefix(base, "bookmarks-"):
+		backup, err := firefox.ParseBookmarkBackup(filename)
+		if err != nil {
+			return err
+		}
+		t.value = backup
+		if backup.Bookmarks != nil {
+			flattenFirefox(t, "", backup.Bookmarks.Children)
+		}
+	case base == "Bookmarks" || base == "Bookmarks.bak":
+		b, err := chrome.ParseBookmarks(filename)
+		if err != nil {
+			return err
+		}
+		t.value = b
+		for _, root := range []chrome.BookmarkEntry{b.Roots.BookmarkBar, b.Roots.Other, b.Roots.Synced} {
+			flattenChrome(t, root.Name, root.Children)
+		}
+	default:
+		return fmt.Errorf("unrecognized bookmarks file: %q", base)
+	}
+	return t.print(os.Stdout, *format)
+}
+
+func flattenHTML(t *table, folder string, entries []bookmark.BookmarkEntry) {
+	for _, entry := range entries {
+		switch e := entry.(type) {
+		case *bookmark.BookmarkFolder:
+			flattenHTML(t, joinFolder(folder, e.Title), e.Entries)
+		case *bookmark.Bookmark:
+			t.append(folder, e.Title, e.URL, formatTime(e.AddDate))
+		}
+	}
+}
+
+func flattenFirefox(t *table, folder string, entries []firefox.BookmarkBackupEntry) {
+	for _, e := range entries {
+		if e.Children != nil || e.URI == "" {
+			flattenFirefox(t, joinFolder(folder, e.Title), e.Children)
+			continue
+		}
+		t.append(folder, e.Title, e.URI, formatTime(e.DateAdded.Time))
+	}
+}
+
+func flattenChrome(t *table, folder string, entries []chrome.BookmarkEntry) {
+	for _, e := range entries {
+		if e.Type == "folder" {
+			flattenChrome(t, joinFolder(folder, e.Name), e.Children)
+			continue
+		}
+		t.append(folder, e.Name, e.URL, formatTime(e.DateAdded.Time))
+	}
+}
+
+func joinFolder(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "/" + name
+}
+
+// formatTime formats a time for tabular output.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/andrewarchi/browser/extensions/historytrends"
+	"github.com/andrewarchi/browser/history"
+)
+
+func runConvert(args []string) error {
+	fs := newFlagSet("convert", "input output")
+	from := fs.String("from", "", "input format (default: detected from filename)")
+	to := fs.String("to", "", "output format (default: detected from filename); one of "+formatList())
+	filters := historyFilterFlags(fs)
+	fs.Parse(args)
+	if err := requireArgs(fs, 2); err != nil {
+		return err
+	}
+	filter, err := filters()
+	if err != nil {
+		return err
+	}
+	input, output := fs.Arg(0), fs.Arg(1)
+
+	var dstFormat *history.Format
+	if *to != "" {
+		dstFormat, err = history.Lookup(*to)
+	} else {
+		dstFormat, err = history.Detect(output)
+	}
+	if err != nil {
+		return err
+	}
+	if dstFormat.Create == nil {
+		return fmt.Errorf("format %s cannot be written", dstFormat.Name)
+	}
+
+	src, err := openHistory(input, *from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// Use the export time from the output filename, when it has one.
+	exportTime := time.Now()
+	if _, t, err := historytrends.ParseExportFilename(output); err == nil {
+		exportTime = t
+	}
+
+	dst, err := history.CreateFile(output, dstFormat, exportTime)
+	if err != nil {
+		return err
+	}
+	report, err := history.Convert(dst, dstFormat, history.Filtered(src, filter))
+	if err != nil {
+		dst.Close()
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "converted %s\n", report)
+	return nil
+}
+
+func formatList() string {
+	var names []string
+	for _, f := range history.Formats() {
+		if f.Create != nil {
+			names = append(names, f.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/andrewarchi/browser/firefox"
+	"github.com/andrewarchi/browser/jsonutil"
+	"github.com/andrewarchi/browser/takeout"
+)
+
+func runExtensions(args []string) error {
+	fs := newFlagSet("extensions", "file")
+	format := formatFlag(fs, formatTable)
+	fs.Parse(args)
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+	filename := fs.Arg(0)
+
+	t := &table{header: []string{"ID", "Name", "Version", "Type", "Enabled"}}
+	switch filepath.Base(filename) {
+	case "extensions.json": // Firefox profile
+		ex, err := firefox.ParseExtensions(filename)
+		if err != nil {
+			return err
+		}
+		t.value = ex
+		for _, a := range ex.Addons {
+			var id string
+			if a.ID != nil {
+				id = a.ID.String()
+			}
+			t.append(id, a.DefaultLocale.Name, a.Version, a.Type, strconv.FormatBool(a.Active))
+		}
+	default: // Takeout Extensions.json or export
+		var data *takeout.Chrome
+		if filepath.Ext(filename) == ".json" {
+			data = new(takeout.Chrome)
+			if err := jsonutil.DecodeFile(filename, data); err != nil {
+				return err
+			}
+		} else {
+			var err error
+			if data, err = takeout.ParseChrome(filename); err != nil {
+				return err
+			}
+		}
+		t.value = data.Extensions
+		for _, e := range data.Extensions {
+			t.append(e.ID, e.Name, e.Version, "extension", strconv.FormatBool(e.Enabled))
+		}
+	}
+	return t.print(os.Stdout, *format)
+}
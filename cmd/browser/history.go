@@ -0,0 +1,141 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/andrewarchi/browser/chrome"
+	"github.com/andrewarchi/browser/history"
+)
+
+func runHistory(args []string) error {
+	fs := newFlagSet("history", "file")
+	from := fs.String("from", "", "input format (default: detected from filename)")
+	format := formatFlag(fs, formatTSV)
+	filters := historyFilterFlags(fs)
+	fs.Parse(args)
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+	filter, err := filters()
+	if err != nil {
+		return err
+	}
+
+	src, err := openHistory(fs.Arg(0), *from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	src = history.Filtered(src, filter)
+
+	header := []string{"Time", "Transition", "URL", "Title"}
+	row := func(v *history.Visit) []string {
+		return []string{formatTime(v.Time), v.Transition.String(), v.URL, v.Title}
+	}
+	if *format == formatTSV {
+		// Stream, rather than collecting all visits.
+		w := bufio.NewWriter(os.Stdout)
+		if err := printTSV(w, header, nil); err != nil {
+			return err
+		}
+		err := history.Each(src, func(v *history.Visit) error {
+			return printTSV(w, nil, [][]string{row(v)})
+		})
+		if err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	visits, err := history.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	t := &table{header: header, value: visits}
+	for i := range visits {
+		t.append(row(&visits[i])...)
+	}
+	return t.print(os.Stdout, *format)
+}
+
+// historyFilterFlags registers flags for filtering visits and returns a
+// function to build the filter after parsing.
+func historyFilterFlags(fs *flag.FlagSet) func() (history.Filter, error) {
+	since := fs.String("since", "", "keep visits at or after this time (RFC 3339 or 2006-01-02)")
+	until := fs.String("until", "", "keep visits before this time (RFC 3339 or 2006-01-02)")
+	host := fs.String("host", "", "keep visits to these comma-separated hosts")
+	domain := fs.String("domain", "", "keep visits to these comma-separated domains (eTLD+1)")
+	transition := fs.String("transition", "", "keep visits with these comma-separated core transitions (e.g. link,typed)")
+	title := fs.String("title", "", "keep visits with titles matching this regular expression")
+	return func() (history.Filter, error) {
+		var filters []history.Filter
+		if *since != "" || *until != "" {
+			start, err := parseTimeFlag(*since)
+			if err != nil {
+				return nil, err
+			}
+			end, err := parseTimeFlag(*until)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, history.TimeRange(start, end))
+		}
+		if *host != "" {
+			filters = append(filters, history.Host(strings.Split(*host, ",")...))
+		}
+		if *domain != "" {
+			filters = append(filters, history.Domain(strings.Split(*domain, ",")...))
+		}
+		if *transition != "" {
+			var types []chrome.PageTransition
+			for _, s := range strings.Split(*transition, ",") {
+				typ, err := chrome.PageTransitionFromString(s)
+				if err != nil {
+					return nil, err
+				}
+				types = append(types, typ)
+			}
+			filters = append(filters, history.Transition(types...))
+		}
+		if *title != "" {
+			re, err := regexp.Compile(*title)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, history.Title(re))
+		}
+		return history.And(filters...), nil
+	}
+}
+
+func parseTimeFlag(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+func openHistory(filename, format string) (history.Source, error) {
+	if format == "" {
+		return history.Open(filename)
+	}
+	f, err := history.Lookup(format)
+	if err != nil {
+		return nil, err
+	}
+	return f.Open(filename)
+}
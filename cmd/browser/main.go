@@ -0,0 +1,195 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Command browser inspects and converts browser data.
+//
+// Usage:
+//
+//	browser <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	profiles    list Firefox profiles and installs
+//	bookmarks   print bookmarks from Firefox, Chrome, or HTML files
+//	history     print browsing history from exports
+//	extensions  list Firefox or Takeout Chrome extensions
+//	takeout     print or extract Chrome data in a Takeout export
+//	convert     convert browsing history between formats
+//	merge       merge History Trends Unlimited exports
+//	stats       summarize browsing trends in history
+//
+// Most commands accept -format to select json, tsv, or table output.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type command struct {
+	run   func(args []string) error
+	usage string
+}
+
+var commands map[string]command
+
+func init() {
+	// Initialized in init to break the reference cycle through
+	// newFlagSet.
+	commands = map[string]command{
+		"profiles":   {runProfiles, "list Firefox profiles and installs"},
+		"bookmarks":  {runBookmarks, "print bookmarks from Firefox, Chrome, or HTML files"},
+		"history":    {runHistory, "print browsing history from exports"},
+		"extensions": {runExtensions, "list Firefox or Takeout Chrome extensions"},
+		"takeout":    {runTakeout, "print or extract Chrome data in a Takeout export"},
+		"convert":    {runConvert, "convert browsing history between formats"},
+		"merge":      {runMerge, "merge History Trends Unlimited exports"},
+		"stats":      {runStats, "summarize browsing trends in history"},
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		usage()
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "browser: unknown command %q\n", name)
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "browser %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: browser <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w := tabwriter.NewWriter(os.Stderr, 0, 8, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\t%s\n", name, commands[name].usage)
+	}
+	w.Flush()
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, `Run "browser <command> -h" for help on a command.`)
+}
+
+// newFlagSet creates a flag set for a command with the given argument
+// synopsis.
+func newFlagSet(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		desc := commands[name].usage
+		fmt.Fprintf(fs.Output(), "Usage: browser %s [flags] %s\n\n%s%s.\n\nFlags:\n",
+			name, synopsis, strings.ToUpper(desc[:1]), desc[1:])
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// Output formats:
+const (
+	formatJSON  = "json"
+	formatTSV   = "tsv"
+	formatTable = "table"
+)
+
+// formatFlag registers the -format flag with the given default.
+func formatFlag(fs *flag.FlagSet, def string) *string {
+	return fs.String("format", def, "output format: json, tsv, or table")
+}
+
+// table is tabular output that can also be printed as json.
+type table struct {
+	header []string
+	rows   [][]string
+	value  interface{} // value printed for json
+}
+
+func (t *table) append(row ...string) {
+	t.rows = append(t.rows, row)
+}
+
+// print writes the table in the given format.
+func (t *table) print(w io.Writer, format string) error {
+	switch format {
+	case formatJSON:
+		return printJSON(w, t.value)
+	case formatTSV:
+		return printTSV(w, t.header, t.rows)
+	case formatTable:
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, joinFields(t.header))
+		for _, row := range t.rows {
+			fmt.Fprintln(tw, joinFields(row))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported format: %q", format)
+	}
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	e.SetEscapeHTML(false)
+	return e.Encode(v)
+}
+
+// printTSV writes tab-separated records. Tabs and newlines within
+// fields are replaced with spaces.
+func printTSV(w io.Writer, header []string, rows [][]string) error {
+	if header != nil {
+		rows = append([][]string{header}, rows...)
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintln(w, joinFields(row)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var fieldReplacer = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+
+// joinFields joins fields with tabs, replacing tabs and newlines within
+// fields with spaces, so that they do not break the columns.
+func joinFields(row []string) string {
+	fields := make([]string, len(row))
+	for i, field := range row {
+		fields[i] = fieldReplacer.Replace(field)
+	}
+	return strings.Join(fields, "\t")
+}
+
+// requireArgs checks the number of positional arguments.
+func requireArgs(fs *flag.FlagSet, n int) error {
+	if fs.NArg() != n {
+		fs.Usage()
+		return fmt.Errorf("expected %d arguments, got %d", n, fs.NArg())
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTablePrint(t *testing.T) {
+	newTable := func() *table {
+		return &table{
+			header: []string{"Title", "URL"},
+			rows: [][]string{
+				{"Example\tDomain", "https://example.com/"},
+				{"Article\nTitle", "https://example.org/article"},
+			},
+			value: map[string]int{"count": 2},
+		}
+	}
+	tests := []struct {
+		format, want string
+	}{
+		{formatJSON, "{\n  \"count\": 2\n}\n"},
+		{formatTSV, "Title\tURL\nExample Domain\thttps://example.com/\nArticle Title\thttps://example.org/article\n"},
+		{formatTable, "Title           URL\nExample Domain  https://example.com/\nArticle Title   https://example.org/article\n"},
+	}
+	for _, test := range tests {
+		tbl := newTable()
+		var b strings.Builder
+		if err := tbl.print(&b, test.format); err != nil {
+			t.Errorf("%s: %v", test.format, err)
+			continue
+		}
+		if got := b.String(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.format, got, test.want)
+		}
+		if !reflect.DeepEqual(tbl, newTable()) {
+			t.Errorf("%s: table modified when printing", test.format)
+		}
+	}
+	if err := newTable().print(&strings.Builder{}, "xml"); err == nil {
+		t.Error("xml: expected error")
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/andrewarchi/browser/extensions/historytrends"
+)
+
+func runMerge(args []string) error {
+	fs := newFlagSet("merge", "history_autobackup_YYYYMMDD_full.{tsv|zip} input...")
+	verbose := fs.Bool("v", false, "print conflicting visits")
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return fmt.Errorf("expected at least 2 arguments, got %d", fs.NArg())
+	}
+	output := fs.Arg(0)
+
+	m := historytrends.NewMerger()
+	for _, input := range fs.Args()[1:] {
+		if err := m.AddFile(input); err != nil {
+			return err
+		}
+	}
+	// The output is named as an export, so that the extension can
+	// restore it, and the type and time are taken from the name.
+	n, err := historytrends.ParseExportName(output)
+	if err != nil {
+		return err
+	}
+	fw, err := historytrends.CreateFile(output, n.Type, n.Time)
+	if err != nil {
+		return err
+	}
+	if err := fw.WriteAll(m.Visits()); err != nil {
+		fw.Close()
+		return err
+	}
+	if err := fw.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "merged %d visits with %d conflicts\n", m.Len(), len(m.Conflicts))
+	if *verbose {
+		for _, c := range m.Conflicts {
+			fmt.Fprintln(os.Stderr, c)
+		}
+	}
+	return nil
+}
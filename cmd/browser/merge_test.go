@@ -0,0 +1,58 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andrewarchi/archive"
+	"github.com/andrewarchi/browser/extensions/historytrends"
+)
+
+func TestRunMerge(t *testing.T) {
+	testdata := filepath.Join("..", "..", "extensions", "historytrends", "testdata")
+	inputs := []string{
+		filepath.Join(testdata, "exported_archived_history_20210202.tsv"),
+		filepath.Join(testdata, "history_autobackup_20210203_full.tsv"),
+	}
+	dir := t.TempDir()
+	output := filepath.Join(dir, "history_autobackup_20210204_full.zip")
+	if err := runMerge(append([]string{output}, inputs...)); err != nil {
+		t.Fatal(err)
+	}
+
+	m := historytrends.NewMerger()
+	for _, input := range inputs {
+		if err := m.AddFile(input); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rc, name, err := archive.OpenSingleFileZip(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if name != "history_autobackup_20210204_full.tsv" {
+		t.Errorf("got file %q in zip, want history_autobackup_20210204_full.tsv", name)
+	}
+	ex, err := historytrends.NewReader(rc, time.Date(2021, 2, 4, 0, 0, 0, 0, time.UTC)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex.Type != historytrends.ArchivedExport {
+		t.Errorf("got %s export, want archived", ex.Type)
+	}
+	if len(ex.Visits) != m.Len() {
+		t.Errorf("got %d visits, want %d", len(ex.Visits), m.Len())
+	}
+
+	if err := runMerge(append([]string{filepath.Join(dir, "merged.zip")}, inputs...)); err == nil {
+		t.Error("merged.zip: expected error for name that is not an export")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/andrewarchi/browser/firefox"
+)
+
+func runProfiles(args []string) error {
+	fs := newFlagSet("profiles", "")
+	dir := fs.String("dir", "", "Firefox root containing profiles.ini (default: platform location)")
+	installs := fs.Bool("installs", false, "list installs and their default profiles, instead of profiles")
+	format := formatFlag(fs, formatTable)
+	fs.Parse(args)
+	if err := requireArgs(fs, 0); err != nil {
+		return err
+	}
+	if *dir == "" {
+		root, err := firefoxRoot()
+		if err != nil {
+			return err
+		}
+		*dir = root
+	}
+
+	info, err := firefox.ParseProfiles(*dir)
+	if err != nil {
+		return err
+	}
+	if *installs {
+		t := &table{
+			header: []string{"ID", "Default", "Locked"},
+			value:  info.Installs,
+		}
+		for _, in := range info.Installs {
+			t.append(fmt.Sprintf("%X", in.ID), in.Default, strconv.FormatBool(in.Locked))
+		}
+		return t.print(os.Stdout, *format)
+	}
+	t := &table{
+		header: []string{"ID", "Name", "Default", "Path"},
+		value:  info,
+	}
+	for _, p := range info.Profiles {
+		t.append(strconv.Itoa(p.ID), p.Name, strconv.FormatBool(p.Default), p.AbsPath(*dir))
+	}
+	return t.print(os.Stdout, *format)
+}
+
+// firefoxRoot returns the directory containing profiles.ini. On Linux,
+// profiles are stored directly in the root, but on other platforms,
+// they are in a Profiles subdirectory.
+func firefoxRoot() (string, error) {
+	dir, err := firefox.ProfilesDir()
+	if err != nil {
+		return "", fmt.Errorf("locate Firefox profiles: %w", err)
+	}
+	if runtime.GOOS == "linux" {
+		return dir, nil
+	}
+	return filepath.Dir(dir), nil
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/andrewarchi/browser/chrome"
+	"github.com/andrewarchi/browser/extensions/historytrends"
+	"github.com/andrewarchi/browser/history"
+	"github.com/andrewarchi/browser/history/analytics"
+)
+
+func runStats(args []string) error {
+	fs := newFlagSet("stats", "file")
+	from := fs.String("from", "", "input format (default: detected from filename)")
+	tz := fs.String("tz", "", "timezone for hours of the day (default: export timezone or UTC)")
+	idle := fs.Duration("idle", 30*time.Minute, "idle time that ends a session")
+	top := fs.Int("top", 10, "number of domains to list")
+	format := formatFlag(fs, formatTable)
+	filters := historyFilterFlags(fs)
+	fs.Parse(args)
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+	filter, err := filters()
+	if err != nil {
+		return err
+	}
+	filename := fs.Arg(0)
+
+	var stats *analytics.Stats
+	if _, err := historytrends.ParseExportName(filename); err == nil && *tz == "" && *from == "" {
+		// Use the timezone recovered from the export.
+		r, err := historytrends.OpenReader(filename)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		stats, err = analytics.AnalyzeExport(&r.Reader, *idle, filter)
+		if err != nil {
+			return err
+		}
+	} else {
+		loc := time.UTC
+		if *tz != "" {
+			if loc, err = time.LoadLocation(*tz); err != nil {
+				return err
+			}
+		}
+		src, err := openHistory(filename, *from)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		stats, err = analytics.Analyze(history.Filtered(src, filter), loc, *idle)
+		if err != nil {
+			return err
+		}
+	}
+
+	sessions := stats.Sessions()
+	var sessionTime time.Duration
+	for _, s := range sessions {
+		sessionTime += s.Duration()
+	}
+	summary := &table{header: []string{"Metric", "Value"}}
+	summary.append("visits", strconv.Itoa(stats.Visits))
+	summary.append("domains", strconv.Itoa(len(stats.Domains)))
+	summary.append("sessions", strconv.Itoa(len(sessions)))
+	summary.append("session time", sessionTime.String())
+	summary.append("typed/link", formatFloat(stats.TypedLinkRatio()))
+	for typ := chrome.TransitionFirst; typ <= chrome.TransitionLastCore; typ++ {
+		if n := stats.Transitions[typ]; n != 0 {
+			summary.append(typ.String(), strconv.Itoa(n))
+		}
+	}
+
+	domains := &table{header: []string{"Domain", "Visits"}}
+	for _, c := range stats.TopDomains(*top) {
+		domains.append(c.Key, strconv.Itoa(c.Visits))
+	}
+
+	heatmap := &table{header: []string{"Day"}}
+	for h := 0; h < 24; h++ {
+		heatmap.header = append(heatmap.header, strconv.Itoa(h))
+	}
+	for d, hours := range stats.Heatmap {
+		row := []string{time.Weekday(d).String()[:3]}
+		for _, n := range hours {
+			row = append(row, strconv.Itoa(n))
+		}
+		heatmap.append(row...)
+	}
+
+	if *format == formatJSON {
+		return printJSON(os.Stdout, struct {
+			Stats      *analytics.Stats
+			TopDomains []analytics.Count
+			Sessions   []analytics.Session
+		}{stats, stats.TopDomains(*top), sessions})
+	}
+	for i, t := range []*table{summary, domains, heatmap} {
+		if i != 0 {
+			fmt.Println()
+		}
+		if err := t.print(os.Stdout, *format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatFloat(f float64) string {
+	if math.IsNaN(f) {
+		return "-"
+	}
+	return strconv.FormatFloat(f, 'f', 3, 64)
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/andrewarchi/browser/takeout"
+)
+
+func runTakeout(args []string) error {
+	fs := newFlagSet("takeout", "takeout-YYYYMMDDTHHMMSSZ-001.{zip|tgz}")
+	extract := fs.String("extract", "", "extract Chrome files to a directory, instead of printing")
+	allowUnknown := fs.Bool("allow-unknown", false, "list unrecognized files, instead of failing")
+	index := fs.Bool("index", false, "list the services and files in all parts, instead of parsing Chrome data")
+	check := fs.Bool("check", false, "verify that no parts are missing before the last part found, instead of parsing Chrome data")
+	diff := fs.String("diff", "", "compare Chrome data to another export, ordered by export time, instead of printing")
+	workers := fs.Int("workers", 0, "maximum number of parts to read concurrently (0 for the number of CPUs)")
+	format := formatFlag(fs, formatJSON)
+	fs.Parse(args)
+	if err := requireArgs(fs, 1); err != nil {
+		return err
+	}
+	filename := fs.Arg(0)
+
+	if *extract != "" {
+		return takeout.ExtractChrome(filename, *extract)
+	}
+	if *index {
+		return printIndex(filename, *format)
+	}
+	if *check {
+		return checkComplete(filename)
+	}
+	opts := &takeout.Options{
+		Chrome:  takeout.ChromeOptions{AllowUnknownFiles: *allowUnknown},
+		Workers: *workers,
+	}
+	if *diff != "" {
+		return printDiff(*diff, filename, opts, *format)
+	}
+	data, err := parseChrome(filename, opts)
+	if err != nil {
+		return err
+	}
+	t := &table{
+		header: []string{"Section", "Count"},
+		value:  data,
+	}
+	for _, s := range []struct {
+		name  string
+		count int
+	}{
+		{"Autofill", len(data.Autofill) + len(data.AutofillProfile)},
+		{"Credit Cards", len(data.CreditCards)},
+		{"Bookmarks", len(data.Bookmarks)},
+		{"Browser History", len(data.BrowserHistory)},
+		{"Device Info", len(data.DeviceInfo)},
+		{"Dictionary", len(data.Dictionary)},
+		{"Extensions", len(data.Extensions)},
+		{"Extension Settings", len(data.ExtensionSettings)},
+		{"Search Engines", len(data.SearchEngines)},
+		{"Apps", len(data.Apps)},
+		{"App Settings", len(data.AppSettings)},
+		{"Preferences", len(data.Preferences)},
+		{"Themes", len(data.Themes)},
+		{"OS Preferences", len(data.OSPreferences) + len(data.OSPriorityPreferences)},
+		{"Reading List", len(data.ReadingList)},
+	} {
+		t.append(s.name, strconv.Itoa(s.count))
+	}
+	return t.print(os.Stdout, *format)
+}
+
+// parseChrome parses the Chrome data in an export and lists any
+// unknown files.
+func parseChrome(filename string, opts *takeout.Options) (*takeout.Chrome, error) {
+	ex, err := takeout.NewExport(filename)
+	if err != nil {
+		return nil, err
+	}
+	res, err := ex.Parse(opts, "Chrome")
+	if err != nil {
+		return nil, err
+	}
+	data := res.Results["Chrome"].(*takeout.Chrome)
+	for _, name := range data.UnknownFiles {
+		fmt.Fprintf(os.Stderr, "takeout: unknown file: %s: %s\n", filename, name)
+	}
+	return data, nil
+}
+
+func printIndex(filename, format string) error {
+	ex, err := takeout.NewExport(filename)
+	if err != nil {
+		return err
+	}
+	idx, err := ex.Index()
+	if err != nil {
+		return err
+	}
+	t := &table{
+		header: []string{"Service", "Files", "Size", "Parts"},
+		value:  idx,
+	}
+	for _, s := range idx.Services {
+		parts := make([]string, len(s.Parts))
+		for i, p := range s.Parts {
+			parts[i] = filepath.Base(idx.Parts[p])
+		}
+		t.append(s.Name, strconv.Itoa(len(s.Files)), strconv.FormatInt(s.Size, 10), strings.Join(parts, ","))
+	}
+	return t.print(os.Stdout, format)
+}
+
+func checkComplete(filename string) error {
+	ex, err := takeout.NewExport(filename)
+	if err != nil {
+		return err
+	}
+	for _, n := range ex.Missing {
+		fmt.Printf("missing part: %03d\n", n)
+	}
+	if len(ex.Missing) != 0 {
+		return fmt.Errorf("export %s is incomplete", ex.Timestamp)
+	}
+	fmt.Printf("export %s has no missing parts up to part %03d\n", ex.Timestamp, ex.Numbers[len(ex.Numbers)-1])
+	return nil
+}
+
+func printDiff(older, newer string, opts *takeout.Options, format string) error {
+	oldData, err := parseChrome(older, opts)
+	if err != nil {
+		return err
+	}
+	newData, err := parseChrome(newer, opts)
+	if err != nil {
+		return err
+	}
+	d := takeout.DiffChrome(oldData, newData)
+	t := &table{
+		header: []string{"Section", "Added", "Removed", "Modified"},
+		value:  d,
+	}
+	counts := func(n int, kind func(i int) takeout.ChangeKind) [3]int {
+		var c [3]int // added, removed, modified
+		for i := 0; i < n; i++ {
+			switch kind(i) {
+			case takeout.Added:
+				c[0]++
+			case takeout.Removed:
+				c[1]++
+			case takeout.Modified:
+				c[2]++
+			}
+		}
+		return c
+	}
+	for _, s := range []struct {
+		name   string
+		counts [3]int
+	}{
+		{"Bookmarks", [3]int{len(d.AddedBookmarks), len(d.RemovedBookmarks), 0}},
+		{"Browser History", [3]int{len(d.NewVisits), len(d.DeletedVisits), 0}},
+		{"Extensions", counts(len(d.Extensions), func(i int) takeout.ChangeKind { return d.Extensions[i].Kind })},
+		{"Search Engines", counts(len(d.SearchEngines), func(i int) takeout.ChangeKind { return d.SearchEngines[i].Kind })},
+		{"Autofill", counts(len(d.Autofill), func(i int) takeout.ChangeKind { return d.Autofill[i].Kind })},
+		{"Credit Cards", counts(len(d.CreditCards), func(i int) takeout.ChangeKind { return d.CreditCards[i].Kind })},
+	} {
+		t.append(s.name, strconv.Itoa(s.counts[0]), strconv.Itoa(s.counts[1]), strconv.Itoa(s.counts[2]))
+	}
+	return t.print(os.Stdout, format)
+}
@@ -73,13 +73,21 @@ func (r *Reader) readAnalysisVisit(rawURL, host, domain, timeMsec, timeLocal, we
 	if err != nil {
 		return nil, err
 	}
+	if typ.Qualifiers() != 0 {
+		return nil, fmt.Errorf("transition %q has qualifiers", transition)
+	}
 
-	return &Visit{
+	v := &Visit{
 		URL:        rawURL,
 		VisitTime:  t,
 		Transition: typ,
 		PageTitle:  normalizeTitle(title),
-	}, nil
+		CoreOnly:   true,
+	}
+	if r.enricher != nil {
+		r.enricher.Enrich(v)
+	}
+	return v, nil
 }
 
 func parseTimes(timeMsec, timeLocal, weekday string) (time.Time, int, error) {
@@ -93,11 +101,11 @@ func parseTimes(timeMsec, timeLocal, weekday string) (time.Time, int, error) {
 	// UTC with sub-millisecond precision. timeLocal is in the local
 	// timezone at the time of export and has truncated millisecond
 	// precision.
-	diff := msec.Truncate(time.Millisecond).Sub(local)
+	diff := local.Sub(msec.Truncate(time.Millisecond))
 	if diff%time.Second != 0 {
 		return time.Time{}, 0, fmt.Errorf("time difference is fractional: %s", diff)
 	}
-	offset := int(diff / time.Second)
+	offset := int(diff / time.Second) // seconds east of UTC
 
 	day, err := strconv.Atoi(weekday)
 	if err != nil {
@@ -140,18 +148,10 @@ func checkURL(rawURL, host, domain string) error {
 
 // writeAnalysisVisit writes a single visit in an analysis export.
 func (w *Writer) writeAnalysisVisit(v *Visit) ([]string, error) {
-	u, err := url.Parse(v.URL)
+	host, tld1, err := HostDomain(v.URL)
 	if err != nil {
 		return nil, err
 	}
-	host := u.Hostname()
-	var tld1 string
-	if strings.IndexByte(host, '.') != -1 {
-		tld1, err = publicsuffix.EffectiveTLDPlusOne(host)
-		if err != nil {
-			return nil, err
-		}
-	}
 	local := v.VisitTime.In(w.loc)
 	return []string{
 		v.URL,
@@ -160,7 +160,25 @@ func (w *Writer) writeAnalysisVisit(v *Visit) ([]string, error) {
 		timefmt.Format(v.VisitTime, timefmt.Milli, timefmt.Unix),
 		local.Format("2006-01-02 15:04:05.000"),
 		strconv.Itoa(int(local.Weekday())),
-		v.Transition.String(),
+		v.Transition.Core().String(),
 		v.PageTitle,
 	}, nil
 }
+
+// HostDomain computes the host and domain (eTLD+1) of a URL, as in
+// analysis exports. The domain is empty for hosts without a dot, such
+// as localhost.
+func HostDomain(rawURL string) (host, domain string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", err
+	}
+	host = u.Hostname()
+	if strings.IndexByte(host, '.') != -1 {
+		domain, err = publicsuffix.EffectiveTLDPlusOne(host)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return host, domain, nil
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package historytrends
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Backup summarizes an auto backup file.
+type Backup struct {
+	Filename string
+	Kind     BackupKind
+	Time     time.Time // date of backup from filename
+	Visits   int       // number of visits
+	First    time.Time // earliest visit time; zero when empty
+	Last     time.Time // latest visit time; zero when empty
+}
+
+// ScanBackup reads an auto backup to determine the range of visit
+// times that it contains.
+func ScanBackup(filename string) (*Backup, error) {
+	r, err := OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	if r.Backup() == NotBackup {
+		return nil, fmt.Errorf("historytrends: not an auto backup: %q", filename)
+	}
+	b := &Backup{Filename: filename, Kind: r.Backup(), Time: r.ExportTime()}
+	for {
+		v, err := r.Read()
+		if err == io.EOF {
+			return b, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w (in %s)", err, filename)
+		}
+		b.Visits++
+		if b.First.IsZero() || v.VisitTime.Before(b.First) {
+			b.First = v.VisitTime
+		}
+		if v.VisitTime.After(b.Last) {
+			b.Last = v.VisitTime
+		}
+	}
+}
+
+// Chain is a full backup followed by the incremental backups made
+// after it, ordered by backup date. Each incremental backup contains
+// the visits since the previous backup.
+type Chain struct {
+	Full         Backup
+	Incrementals []Backup
+	Gaps         []ChainIssue // missing backups between consecutive backups
+	Overlaps     []ChainIssue // visits contained in consecutive backups
+}
+
+// ChainIssue is a problem between two consecutive backups in a chain.
+type ChainIssue struct {
+	Prev, Next string    // filenames
+	Start, End time.Time // affected range
+}
+
+// BuildChains orders backups into chains, each starting at a full
+// backup. Incremental backups dated before the earliest full backup
+// cannot be restored and are returned as orphans.
+//
+// Consecutive backups overlap when the next backup contains visits at
+// or before the latest visit in the previous backup. A gap is reported
+// when consecutive backups are dated further apart than interval, the
+// auto backup frequency, since a backup between them is then likely
+// missing. A zero interval disables gap detection.
+func BuildChains(backups []Backup, interval time.Duration) (chains []Chain, orphans []Backup) {
+	sorted := make([]Backup, len(backups))
+	copy(sorted, backups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].Time.Equal(sorted[j].Time) {
+			return sorted[i].Time.Before(sorted[j].Time)
+		}
+		// A full backup starts a new chain on the same date.
+		return sorted[i].Kind == FullBackup && sorted[j].Kind != FullBackup
+	})
+
+	for _, b := range sorted {
+		switch {
+		case b.Kind == FullBackup:
+			chains = append(chains, Chain{Full: b})
+		case len(chains) == 0:
+			orphans = append(orphans, b)
+		default:
+			c := &chains[len(chains)-1]
+			prev := c.Full
+			if n := len(c.Incrementals); n != 0 {
+				prev = c.Incrementals[n-1]
+			}
+			c.check(prev, b, interval)
+			c.Incrementals = append(c.Incrementals, b)
+		}
+	}
+	return chains, orphans
+}
+
+// check detects issues between consecutive backups.
+func (c *Chain) check(prev, next Backup, interval time.Duration) {
+	if next.Visits != 0 && prev.Visits != 0 && !next.First.After(prev.Last) {
+		end := prev.Last
+		if next.Last.Before(end) {
+			end = next.Last
+		}
+		c.Overlaps = append(c.Overlaps, ChainIssue{prev.Filename, next.Filename, next.First, end})
+	}
+	if interval != 0 && next.Time.Sub(prev.Time) > interval {
+		c.Gaps = append(c.Gaps, ChainIssue{prev.Filename, next.Filename, prev.Time, next.Time})
+	}
+}
+
+// Backups returns the backups in the chain in order.
+func (c *Chain) Backups() []Backup {
+	return append([]Backup{c.Full}, c.Incrementals...)
+}
+
+// Materialize reconstructs the browsing history as of the given time
+// by merging the backups in the chain dated on or before that day,
+// including those with a later time of day, and dropping later visits
+// and their conflicts.
+func (c *Chain) Materialize(asOf time.Time) (*Merger, error) {
+	next := time.Date(asOf.Year(), asOf.Month(), asOf.Day()+1, 0, 0, 0, 0, time.UTC)
+	if !c.Full.Time.Before(next) {
+		return nil, fmt.Errorf("historytrends: full backup %s is after %s",
+			c.Full.Filename, asOf.Format("2006-01-02"))
+	}
+	m := NewMerger()
+	for _, b := range c.Backups() {
+		if !b.Time.Before(next) {
+			break
+		}
+		if err := m.AddFile(b.Filename); err != nil {
+			return nil, err
+		}
+	}
+	for key, v := range m.visits {
+		if v.VisitTime.After(asOf) {
+			delete(m.visits, key)
+		}
+	}
+	conflicts := m.Conflicts[:0]
+	for _, conflict := range m.Conflicts {
+		if !conflict.Kept.VisitTime.After(asOf) {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	m.Conflicts = conflicts
+	return m, nil
+}
+
+// ChainAsOf returns the latest chain with a full backup dated on or
+// before the given time.
+func ChainAsOf(chains []Chain, asOf time.Time) (*Chain, error) {
+	var latest *Chain
+	for i := range chains {
+		c := &chains[i]
+		if !c.Full.Time.After(asOf) && (latest == nil || c.Full.Time.After(latest.Full.Time)) {
+			latest = c
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("historytrends: no full backup on or before %s", asOf.Format("2006-01-02"))
+	}
+	return latest, nil
+}
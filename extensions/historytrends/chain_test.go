@@ -0,0 +1,171 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package historytrends
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildChains(t *testing.T) {
+	dir := t.TempDir()
+	day := func(d int) time.Time { return time.Date(2021, 2, d, 12, 0, 0, 0, time.UTC) }
+	backup := func(name string, days ...int) string {
+		filename := filepath.Join(dir, name)
+		f, err := os.Create(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		w, err := NewWriter(f, ArchivedExport, day(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range days {
+			v := Visit{URL: "https://example.com/", VisitTime: day(d), PageTitle: "Example"}
+			if err := w.Write(&v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	orphan := backup("history_autobackup_20210131_incremental.tsv", 1)
+	full := backup("history_autobackup_20210201_full.tsv", 1)
+	inc2 := backup("history_autobackup_20210202_incremental.tsv", 2)
+	inc4 := backup("history_autobackup_20210204_incremental.tsv", 2, 3, 4) // overlaps and follows gap
+	full2 := backup("history_autobackup_20210205_full.tsv", 1, 2, 3, 4, 5)
+
+	var backups []Backup
+	for _, filename := range []string{inc4, full2, orphan, inc2, full} {
+		b, err := ScanBackup(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backups = append(backups, *b)
+	}
+	chains, orphans := BuildChains(backups, 24*time.Hour)
+
+	if len(orphans) != 1 || orphans[0].Filename != orphan {
+		t.Errorf("got orphans %v, want %s", orphans, orphan)
+	}
+	if len(chains) != 2 {
+		t.Fatalf("got %d chains, want 2", len(chains))
+	}
+	var names []string
+	for _, b := range chains[0].Backups() {
+		names = append(names, b.Filename)
+	}
+	if want := []string{full, inc2, inc4}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got chain %v, want %v", names, want)
+	}
+	wantOverlaps := []ChainIssue{{inc2, inc4, day(2), day(2)}}
+	if !reflect.DeepEqual(chains[0].Overlaps, wantOverlaps) {
+		t.Errorf("got overlaps %v, want %v", chains[0].Overlaps, wantOverlaps)
+	}
+	wantGaps := []ChainIssue{{inc2, inc4, day(2).Truncate(24 * time.Hour), day(4).Truncate(24 * time.Hour)}}
+	if !reflect.DeepEqual(chains[0].Gaps, wantGaps) {
+		t.Errorf("got gaps %v, want %v", chains[0].Gaps, wantGaps)
+	}
+
+	for _, test := range []struct {
+		asOf   time.Time
+		full   string
+		visits int
+	}{
+		{day(1), full, 1},
+		{day(3), full, 2},                 // backup dated 4 not included
+		{day(4).Add(-time.Hour), full, 3}, // visit at noon on 4 dropped
+		{day(4), full, 4},
+		{day(6), full2, 5},
+	} {
+		c, err := ChainAsOf(chains, test.asOf)
+		if err != nil {
+			t.Errorf("%s: %v", test.asOf, err)
+			continue
+		}
+		if c.Full.Filename != test.full {
+			t.Errorf("%s: got chain %s, want %s", test.asOf, c.Full.Filename, test.full)
+		}
+		m, err := c.Materialize(test.asOf)
+		if err != nil {
+			t.Errorf("%s: %v", test.asOf, err)
+			continue
+		}
+		if m.Len() != test.visits {
+			t.Errorf("%s: got %d visits, want %d", test.asOf, m.Len(), test.visits)
+		}
+	}
+	if _, err := ChainAsOf(chains, day(0)); err == nil {
+		t.Error("expected error before first full backup")
+	}
+}
+
+func TestMaterialize(t *testing.T) {
+	dir := t.TempDir()
+	day := func(d, hour int) time.Time { return time.Date(2021, 2, d, hour, 0, 0, 0, time.UTC) }
+	backup := func(name string, visits ...Visit) Backup {
+		filename := filepath.Join(dir, name)
+		f, err := os.Create(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		w, err := NewWriter(f, ArchivedExport, day(1, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteAll(visits); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		b, err := ScanBackup(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *b
+	}
+	visit := func(t time.Time, title string) Visit {
+		return Visit{URL: "https://example.com/", VisitTime: t, PageTitle: title}
+	}
+	c := Chain{
+		Full: backup("history_autobackup_20210201_full.tsv",
+			visit(day(1, 12), "Example"), visit(day(3, 18), "Example")),
+		Incrementals: []Backup{
+			// Taken later in the day than the time materialized.
+			backup("history_autobackup_20210203_143000_incremental.tsv",
+				visit(day(3, 12), "Example"), visit(day(3, 18), "Example Domain")),
+		},
+	}
+
+	m, err := c.Materialize(day(3, 12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 2 {
+		t.Errorf("got %d visits, want 2", m.Len())
+	}
+	// The conflicting titles of the visit at 18:00 are dropped with it.
+	if len(m.Conflicts) != 0 {
+		t.Errorf("got conflicts %v, want none", m.Conflicts)
+	}
+
+	m, err = c.Materialize(day(3, 18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 3 || len(m.Conflicts) != 1 {
+		t.Errorf("got %d visits and %d conflicts, want 3 and 1", m.Len(), len(m.Conflicts))
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package historytrends
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/andrewarchi/browser/chrome"
+)
+
+// Enricher restores the transition qualifiers of visits read from
+// analysis exports, which only contain the core transition type. Full
+// transitions are collected from archived exports or the Chrome History
+// database and joined with visits on URL and visit time.
+type Enricher struct {
+	transitions map[visitKey]chrome.PageTransition
+}
+
+// NewEnricher returns an empty Enricher.
+func NewEnricher() *Enricher {
+	return &Enricher{transitions: make(map[visitKey]chrome.PageTransition)}
+}
+
+// Add records the full transition of a visit.
+func (e *Enricher) Add(url string, visitTime time.Time, typ chrome.PageTransition) {
+	e.transitions[visitKey{url, visitTime.UnixNano()}] = typ
+}
+
+// AddExport records the transitions of all visits in r. Visits without
+// qualifiers, such as from analysis exports, are skipped.
+func (e *Enricher) AddExport(r *Reader) error {
+	for {
+		v, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !v.CoreOnly {
+			e.Add(v.URL, v.VisitTime, v.Transition)
+		}
+	}
+}
+
+// AddFile opens an export and records the transitions of its visits.
+func (e *Enricher) AddFile(filename string) error {
+	r, err := OpenReader(filename)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := e.AddExport(&r.Reader); err != nil {
+		return fmt.Errorf("%w (in %s)", err, filename)
+	}
+	return nil
+}
+
+// AddHistoryDB records the transitions of all visits in a Chrome
+// History database.
+func (e *Enricher) AddHistoryDB(db *sql.DB) error {
+	return chrome.ScanHistory(db, func(v *chrome.HistoryVisit) error {
+		e.Add(v.URL, v.VisitTime, v.Transition)
+		return nil
+	})
+}
+
+// Len returns the number of recorded transitions.
+func (e *Enricher) Len() int { return len(e.transitions) }
+
+// Enrich restores the transition qualifiers of a visit without them and
+// reports whether the visit was found. The transition is only replaced
+// when the core types agree.
+func (e *Enricher) Enrich(v *Visit) bool {
+	if !v.CoreOnly {
+		return false
+	}
+	typ, ok := e.transitions[visitKey{v.URL, v.VisitTime.UnixNano()}]
+	if !ok || typ&chrome.TransitionCoreMask != v.Transition&chrome.TransitionCoreMask {
+		return false
+	}
+	v.Transition = typ
+	v.CoreOnly = false
+	return true
+}
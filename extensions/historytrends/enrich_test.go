@@ -0,0 +1,66 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package historytrends
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/andrewarchi/browser/chrome"
+)
+
+func TestEnricher(t *testing.T) {
+	e := NewEnricher()
+	if err := e.AddFile(filepath.Join("testdata", "exported_archived_history_20210202.tsv")); err != nil {
+		t.Fatal(err)
+	}
+	if e.Len() != 3 {
+		t.Errorf("got %d transitions, want 3", e.Len())
+	}
+
+	r, err := OpenReader(filepath.Join("testdata", "exported_analysis_history_20210202_120000.tsv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.SetEnricher(e)
+	ex, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only https://example.com/ is in both exports.
+	want := []struct {
+		transition chrome.PageTransition
+		coreOnly   bool
+	}{
+		{chrome.TransitionLink | chrome.TransitionChainStart | chrome.TransitionChainEnd, false},
+		{chrome.TransitionTyped, true},
+		{chrome.TransitionReload, true},
+	}
+	if len(ex.Visits) != len(want) {
+		t.Fatalf("got %d visits, want %d", len(ex.Visits), len(want))
+	}
+	for i, v := range ex.Visits {
+		if v.Transition != want[i].transition || v.CoreOnly != want[i].coreOnly {
+			t.Errorf("visit %d: got %#x (core-only %t), want %#x (core-only %t)", i,
+				uint32(v.Transition), v.CoreOnly, uint32(want[i].transition), want[i].coreOnly)
+		}
+	}
+}
+
+func TestEnrichCoreMismatch(t *testing.T) {
+	e := NewEnricher()
+	v := Visit{URL: "https://example.com/", Transition: chrome.TransitionTyped, CoreOnly: true}
+	e.Add(v.URL, v.VisitTime, chrome.TransitionLink|chrome.TransitionChainEnd)
+	if e.Enrich(&v) {
+		t.Error("enriched visit with differing core type")
+	}
+	if v.Transition != chrome.TransitionTyped || !v.CoreOnly {
+		t.Errorf("visit modified: %v", v)
+	}
+}
@@ -22,6 +22,7 @@ import (
 type Export struct {
 	Filename   string // filename of tsv within zip or as given
 	Type       ExportType
+	Backup     BackupKind // for auto backups
 	ExportTime time.Time
 	Visits     []Visit
 }
@@ -29,11 +30,16 @@ type Export struct {
 // Visit is a page visit in browsing history. URL and visit time
 // combined are unique; no two visits have the same URL and visit time.
 // The visit time is in UTC, not local time.
+//
+// Analysis exports only contain the core transition type, so CoreOnly
+// is set for visits read from them to mark that any qualifiers were
+// lost. An Enricher can restore the qualifiers.
 type Visit struct {
 	URL        string
 	VisitTime  time.Time // UTC
 	Transition chrome.PageTransition
 	PageTitle  string
+	CoreOnly   bool // Transition qualifiers are unknown
 }
 
 // ExportType is the format of export.
@@ -56,3 +62,27 @@ func (typ ExportType) String() string {
 		return fmt.Sprintf("export(%d)", typ)
 	}
 }
+
+// BackupKind is the kind of an auto backup. Auto backups are archived
+// exports.
+type BackupKind uint8
+
+// Values for BackupKind:
+const (
+	NotBackup         BackupKind = iota // manual export
+	FullBackup                          // all history
+	IncrementalBackup                   // history since the previous backup
+)
+
+func (kind BackupKind) String() string {
+	switch kind {
+	case NotBackup:
+		return "none"
+	case FullBackup:
+		return "full"
+	case IncrementalBackup:
+		return "incremental"
+	default:
+		return fmt.Sprintf("backup(%d)", kind)
+	}
+}
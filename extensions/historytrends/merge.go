@@ -0,0 +1,184 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package historytrends
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/andrewarchi/browser/chrome"
+)
+
+// Merger consolidates visits from many exports into a single history.
+// Visits are deduplicated by URL and visit time, which together are
+// unique. Exports are streamed, so only the merged history is held in
+// memory.
+type Merger struct {
+	visits    map[visitKey]*mergedVisit
+	Conflicts []Conflict // conflicting values for the same visit
+}
+
+type visitKey struct {
+	url  string
+	time int64 // Unix nanoseconds
+}
+
+// mergedVisit is a visit and the time of the export it was taken from.
+type mergedVisit struct {
+	Visit
+	exportTime time.Time
+}
+
+// Conflict is a visit that was read with differing values for the same
+// field. The value from the preferred export is kept.
+type Conflict struct {
+	Field     string // "title" or "transition"
+	Kept      Visit
+	Discarded Visit
+}
+
+func (c Conflict) String() string {
+	kept, discarded := c.Kept.PageTitle, c.Discarded.PageTitle
+	if c.Field == "transition" {
+		kept = fmt.Sprintf("%d", uint32(c.Kept.Transition))
+		discarded = fmt.Sprintf("%d", uint32(c.Discarded.Transition))
+	}
+	return fmt.Sprintf("%s at %s: %s %q kept over %q", c.Kept.URL,
+		c.Kept.VisitTime.Format(time.RFC3339Nano), c.Field, kept, discarded)
+}
+
+// NewMerger returns an empty Merger.
+func NewMerger() *Merger {
+	return &Merger{visits: make(map[visitKey]*mergedVisit)}
+}
+
+// Add reads all visits from r and merges them.
+func (m *Merger) Add(r *Reader) error {
+	for {
+		v, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		m.merge(&mergedVisit{*v, r.ExportTime()})
+	}
+}
+
+// AddFile opens an export and merges its visits.
+func (m *Merger) AddFile(filename string) error {
+	r, err := OpenReader(filename)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := m.Add(&r.Reader); err != nil {
+		return fmt.Errorf("%w (in %s)", err, filename)
+	}
+	return nil
+}
+
+// AddVisits merges visits from an export made at the given time.
+func (m *Merger) AddVisits(visits []Visit, exportTime time.Time) {
+	for _, v := range visits {
+		m.merge(&mergedVisit{v, exportTime})
+	}
+}
+
+// merge adds a visit, resolving conflicts with an existing visit:
+//
+//   - A non-empty title is preferred over an empty title; otherwise
+//     differing titles are resolved in favor of the later export.
+//   - Transitions with known qualifiers, as from archived exports, are
+//     preferred over core-only transitions, as from analysis exports.
+//     Otherwise, differing transitions are resolved in favor of the
+//     later export.
+//
+// Resolutions between two differing non-empty values are recorded as
+// conflicts.
+func (m *Merger) merge(v *mergedVisit) {
+	key := visitKey{v.URL, v.VisitTime.UnixNano()}
+	old, ok := m.visits[key]
+	if !ok {
+		m.visits[key] = v
+		return
+	}
+	later := v.exportTime.After(old.exportTime)
+
+	switch {
+	case v.PageTitle == old.PageTitle, v.PageTitle == "":
+	case old.PageTitle == "":
+		old.PageTitle = v.PageTitle
+	case later:
+		m.conflict("title", v.Visit, old.Visit)
+		old.PageTitle = v.PageTitle
+	default:
+		m.conflict("title", old.Visit, v.Visit)
+	}
+
+	if v.Transition != old.Transition {
+		core := v.Transition&chrome.TransitionCoreMask == old.Transition&chrome.TransitionCoreMask
+		switch {
+		case !old.CoreOnly && v.CoreOnly:
+			if !core {
+				m.conflict("transition", old.Visit, v.Visit)
+			}
+		case old.CoreOnly && !v.CoreOnly:
+			if !core {
+				m.conflict("transition", v.Visit, old.Visit)
+			}
+			old.Transition, old.CoreOnly = v.Transition, false
+		case later:
+			m.conflict("transition", v.Visit, old.Visit)
+			old.Transition = v.Transition
+		default:
+			m.conflict("transition", old.Visit, v.Visit)
+		}
+	}
+
+	if later {
+		old.exportTime = v.exportTime
+	}
+}
+
+func (m *Merger) conflict(field string, kept, discarded Visit) {
+	m.Conflicts = append(m.Conflicts, Conflict{field, kept, discarded})
+}
+
+// Len returns the number of unique visits.
+func (m *Merger) Len() int { return len(m.visits) }
+
+// Visits returns the merged visits, ordered from most recent to least
+// recent, like in exports, then by URL.
+func (m *Merger) Visits() []Visit {
+	visits := make([]Visit, 0, len(m.visits))
+	for _, v := range m.visits {
+		visits = append(visits, v.Visit)
+	}
+	sort.Slice(visits, func(i, j int) bool {
+		if !visits[i].VisitTime.Equal(visits[j].VisitTime) {
+			return visits[i].VisitTime.After(visits[j].VisitTime)
+		}
+		return visits[i].URL < visits[j].URL
+	})
+	return visits
+}
+
+// Write writes the merged visits as a single archived export.
+func (m *Merger) Write(w io.Writer, exportTime time.Time) error {
+	ew, err := NewWriter(w, ArchivedExport, exportTime)
+	if err != nil {
+		return err
+	}
+	if err := ew.WriteAll(m.Visits()); err != nil {
+		return err
+	}
+	return ew.Flush()
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package historytrends
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andrewarchi/browser/chrome"
+	"github.com/andrewarchi/browser/internal/golden"
+)
+
+func TestMerger(t *testing.T) {
+	m := NewMerger()
+	for _, name := range []string{
+		"exported_archived_history_20210202.tsv",
+		"history_autobackup_20210203_full.tsv",
+		"exported_analysis_history_20210202_120000.tsv",
+	} {
+		if err := m.AddFile(filepath.Join("testdata", name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(m.Conflicts) != 0 {
+		t.Errorf("unexpected conflicts: %v", m.Conflicts)
+	}
+	if m.Len() != 6 {
+		t.Errorf("got %d visits, want 6", m.Len())
+	}
+	var b bytes.Buffer
+	if err := m.Write(&b, time.Date(2021, 2, 4, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	golden.CheckBytes(t, "merged_archived_history.tsv", b.Bytes())
+}
+
+func TestMergerConflicts(t *testing.T) {
+	visitTime := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	older := time.Date(2021, 2, 2, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC)
+	visit := func(title string, typ chrome.PageTransition) Visit {
+		return Visit{"https://example.com/", visitTime, typ, title, false}
+	}
+	coreOnly := func(title string, typ chrome.PageTransition) Visit {
+		v := visit(title, typ)
+		v.CoreOnly = true
+		return v
+	}
+	qualified := chrome.TransitionLink | chrome.TransitionChainEnd
+
+	tests := []struct {
+		name      string
+		adds      []mergedVisit
+		want      Visit
+		conflicts int
+	}{
+		{"empty title", []mergedVisit{
+			{visit("Title", qualified), newer},
+			{visit("", qualified), older},
+		}, visit("Title", qualified), 0},
+		{"later title", []mergedVisit{
+			{visit("New", qualified), newer},
+			{visit("Old", qualified), older},
+		}, visit("New", qualified), 1},
+		{"archived qualifiers", []mergedVisit{
+			{coreOnly("Title", chrome.TransitionLink), newer},
+			{visit("Title", qualified), older},
+		}, visit("Title", qualified), 0},
+		{"archived core", []mergedVisit{
+			{coreOnly("Title", chrome.TransitionTyped), newer},
+			{visit("Title", qualified), older},
+		}, visit("Title", qualified), 1},
+		{"later transition", []mergedVisit{
+			{visit("Title", chrome.TransitionTyped), older},
+			{visit("Title", chrome.TransitionReload), newer},
+		}, visit("Title", chrome.TransitionReload), 1},
+	}
+	for _, test := range tests {
+		m := NewMerger()
+		for _, v := range test.adds {
+			m.AddVisits([]Visit{v.Visit}, v.exportTime)
+		}
+		visits := m.Visits()
+		if len(visits) != 1 {
+			t.Errorf("%s: got %d visits, want 1", test.name, len(visits))
+			continue
+		}
+		if visits[0] != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, visits[0], test.want)
+		}
+		if len(m.Conflicts) != test.conflicts {
+			t.Errorf("%s: got %d conflicts, want %d", test.name, len(m.Conflicts), test.conflicts)
+		}
+	}
+}
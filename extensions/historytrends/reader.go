@@ -22,10 +22,12 @@ import (
 type Reader struct {
 	cr       *csv.Reader
 	typ      ExportType
+	backup   BackupKind
 	filename string    // filename of tsv within zip or as given
 	time     time.Time // export time
 	tz       int       // timezone offset in seconds (analysis exports-only)
 	record   int       // index of record
+	enricher *Enricher // restores qualifiers (analysis exports-only)
 }
 
 // ReadCloser reads and closes a History Trends Unlimited browsing
@@ -56,7 +58,7 @@ func OpenReader(filename string) (*ReadCloser, error) {
 	}
 	// Use the filename inside of the zip, when possible, to recover the
 	// original name for renamed files.
-	typ, exportTime, err := ParseExportFilename(name)
+	n, err := ParseExportName(name)
 	if err != nil {
 		return nil, err
 	}
@@ -67,9 +69,10 @@ func OpenReader(filename string) (*ReadCloser, error) {
 	rc := &ReadCloser{
 		Reader: Reader{
 			cr:       cr,
-			typ:      typ,
+			typ:      n.Type,
+			backup:   n.Backup,
 			filename: filepath.Base(name),
-			time:     exportTime,
+			time:     n.Time,
 		},
 		rc: r,
 	}
@@ -105,18 +108,26 @@ func openExport(filename string) (io.ReadCloser, string, error) {
 // history_autobackup_{date:20060102}_{full|incremental}.{txt|zip} (>= 1.4.1)
 var filenamePattern = regexp.MustCompile(
 	`^(?:exported_(analysis|archived)_history_(\d{8}(?:_\d{6})?)` +
-		`|(?:history_autobackup_(\d{8}(?:_\d{6})?)_(?:full|incremental)))` +
+		`|(?:history_autobackup_(\d{8}(?:_\d{6})?)_(full|incremental)))` +
 		`(?:[^\d].*)?` + // suffix
 		`\.(?:tsv|txt|zip)$`)
 
-// ParseExportFilename extracts the type and time of export from the
-// given filename. A suffix like "(1)" or "copy", for example, is
-// permitted.
-func ParseExportFilename(filename string) (ExportType, time.Time, error) {
+// ExportName is the information encoded in an export filename.
+type ExportName struct {
+	Type     ExportType
+	Backup   BackupKind
+	Time     time.Time // UTC, as the timezone is not recorded
+	WithTime bool      // includes the time of day, as in analysis exports since v1.5.2
+}
+
+// ParseExportName extracts the type, backup kind, and time of export
+// from the given filename. A suffix like "(1)" or "copy", for example,
+// is permitted.
+func ParseExportName(filename string) (*ExportName, error) {
 	base := filepath.Base(filename)
 	matches := filenamePattern.FindStringSubmatch(base)
-	if len(matches) != 4 {
-		return 0, time.Time{}, fmt.Errorf("historytrends: not an export: filename %q does not match pattern", base)
+	if len(matches) != 5 {
+		return nil, fmt.Errorf("historytrends: not an export: filename %q does not match pattern", base)
 	}
 
 	exportTime := matches[2]
@@ -125,14 +136,29 @@ func ParseExportFilename(filename string) (ExportType, time.Time, error) {
 	}
 	t, err := time.Parse("20060102_150405"[:len(exportTime)], exportTime)
 	if err != nil {
-		return 0, time.Time{}, err
+		return nil, err
 	}
 
-	typ := ArchivedExport
-	if matches[1] == "analysis" {
-		typ = AnalysisExport
+	n := &ExportName{Type: ArchivedExport, Time: t, WithTime: len(exportTime) != 8}
+	switch {
+	case matches[1] == "analysis":
+		n.Type = AnalysisExport
+	case matches[4] == "full":
+		n.Backup = FullBackup
+	case matches[4] == "incremental":
+		n.Backup = IncrementalBackup
+	}
+	return n, nil
+}
+
+// ParseExportFilename extracts the type and time of export from the
+// given filename, like ParseExportName.
+func ParseExportFilename(filename string) (ExportType, time.Time, error) {
+	n, err := ParseExportName(filename)
+	if err != nil {
+		return 0, time.Time{}, err
 	}
-	return typ, t, nil
+	return n.Type, n.Time, nil
 }
 
 // Read reads a single visit in an export.
@@ -184,7 +210,7 @@ func (r *Reader) ReadAll() (*Export, error) {
 	for {
 		visit, err := r.Read()
 		if err == io.EOF {
-			return &Export{r.filename, r.typ, r.time, visits}, nil
+			return &Export{r.filename, r.typ, r.backup, r.time, visits}, nil
 		}
 		if err != nil {
 			return nil, err
@@ -198,6 +224,18 @@ func (r *Reader) ReadAll() (*Export, error) {
 // record. For archived exports, the timezone is always UTC.
 func (r *Reader) ExportTime() time.Time { return r.time }
 
+// Type returns the export type. For readers created with NewReader, the
+// type is determined upon reading the first record.
+func (r *Reader) Type() ExportType { return r.typ }
+
+// Backup returns the kind of auto backup, as determined by the
+// filename. It is NotBackup for readers created with NewReader.
+func (r *Reader) Backup() BackupKind { return r.backup }
+
+// SetEnricher sets an Enricher to restore the transition qualifiers of
+// visits read from an analysis export.
+func (r *Reader) SetEnricher(e *Enricher) { r.enricher = e }
+
 // Close closes the underlying io.ReadCloser.
 func (r *ReadCloser) Close() error { return r.rc.Close() }
 
@@ -0,0 +1,112 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package historytrends
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andrewarchi/browser/internal/golden"
+)
+
+func TestReadAll(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.tsv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Zip the autobackup, as the extension does.
+	zipName := filepath.Join(t.TempDir(), "history_autobackup_20210203_full.zip")
+	writeTestZip(t, zipName, filepath.Join("testdata", "history_autobackup_20210203_full.tsv"))
+	files = append(files, zipName)
+
+	for _, filename := range files {
+		r, err := OpenReader(filename)
+		if err != nil {
+			t.Errorf("%s: %v", filename, err)
+			continue
+		}
+		ex, err := r.ReadAll()
+		r.Close()
+		if err != nil {
+			t.Errorf("%s: %v", filename, err)
+			continue
+		}
+		golden.Check(t, filepath.Base(filename), ex)
+	}
+}
+
+// writeTestZip writes a zip containing a single file.
+func writeTestZip(t *testing.T, filename, file string) {
+	t.Helper()
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	w, err := zw.Create(filepath.Base(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+func TestParseExportName(t *testing.T) {
+	date := time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		filename string
+		want     ExportName
+	}{
+		{"history_autobackup_20210203_full.zip", ExportName{ArchivedExport, FullBackup, date, false}},
+		{"history_autobackup_20210203_incremental (1).tsv", ExportName{ArchivedExport, IncrementalBackup, date, false}},
+		{"exported_archived_history_20210203.txt", ExportName{ArchivedExport, NotBackup, date, false}},
+		{"exported_analysis_history_20210203_120000.tsv", ExportName{AnalysisExport, NotBackup, date.Add(12 * time.Hour), true}},
+	}
+	for _, test := range tests {
+		got, err := ParseExportName(test.filename)
+		if err != nil {
+			t.Errorf("%s: %v", test.filename, err)
+			continue
+		}
+		if *got != test.want {
+			t.Errorf("%s: got %v, want %v", test.filename, *got, test.want)
+		}
+	}
+}
+
+func TestParseTimes(t *testing.T) {
+	tests := []struct {
+		timeMsec, timeLocal, weekday string
+		offset                       int
+	}{
+		{"1612224000123.456", "2021-02-01 19:00:00.123", "1", -5 * 60 * 60}, // EST
+		{"1612224000000", "2021-02-02 09:30:00.000", "2", 9*60*60 + 30*60},  // ACST
+		{"1612224000000", "2021-02-02 00:00:00.000", "2", 0},
+	}
+	for _, test := range tests {
+		_, offset, err := parseTimes(test.timeMsec, test.timeLocal, test.weekday)
+		if err != nil {
+			t.Errorf("%s: %v", test.timeLocal, err)
+			continue
+		}
+		if offset != test.offset {
+			t.Errorf("%s: got offset %d, want %d seconds east of UTC", test.timeLocal, offset, test.offset)
+		}
+	}
+}
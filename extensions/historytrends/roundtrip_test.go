@@ -0,0 +1,52 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package historytrends
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "exported_*.tsv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range files {
+		r, err := OpenReader(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ex, err := r.ReadAll()
+		r.Close()
+		if err != nil {
+			t.Errorf("%s: %v", filename, err)
+			continue
+		}
+		var b bytes.Buffer
+		w, err := NewWriter(&b, ex.Type, ex.ExportTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteAll(ex.Visits); err != nil {
+			t.Errorf("%s: %v", filename, err)
+			continue
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		want, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b.Bytes(), want) {
+			t.Errorf("%s: round trip differs:\n%s\nwant:\n%s", filename, b.Bytes(), want)
+		}
+	}
+}
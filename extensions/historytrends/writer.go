@@ -7,10 +7,17 @@
 package historytrends
 
 import (
+	"archive/zip"
 	"bufio"
+	"database/sql"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/andrewarchi/browser/chrome"
 )
 
 // Writer writes a History Trends Unlimited browsing history export.
@@ -98,3 +105,144 @@ func (ex *Export) Write(w io.Writer) error {
 	}
 	return ew.WriteAll(ex.Visits)
 }
+
+// Filename formats the filename for an export with the given
+// extension, which is ".tsv", ".txt" (before v1.4.3), or ".zip". The
+// time is formatted in its location, as the extension uses the local
+// time.
+func (n *ExportName) Filename(ext string) (string, error) {
+	switch ext {
+	case ".tsv", ".txt", ".zip":
+	default:
+		return "", fmt.Errorf("historytrends: bad file extension: %q", ext)
+	}
+	layout := "20060102"
+	if n.WithTime {
+		layout = "20060102_150405"
+	}
+	date := n.Time.Format(layout)
+	switch {
+	case n.Backup == FullBackup || n.Backup == IncrementalBackup:
+		if n.Type != ArchivedExport {
+			return "", fmt.Errorf("historytrends: auto backup cannot be %s export", n.Type)
+		}
+		return "history_autobackup_" + date + "_" + n.Backup.String() + ext, nil
+	case n.Backup != NotBackup:
+		return "", fmt.Errorf("historytrends: illegal backup kind: %s", n.Backup)
+	case n.Type == AnalysisExport || n.Type == ArchivedExport:
+		return "exported_" + n.Type.String() + "_history_" + date + ext, nil
+	default:
+		return "", fmt.Errorf("historytrends: illegal export type: %s", n.Type)
+	}
+}
+
+// FileWriter writes an export to a file, which is either a bare tsv or
+// a zip containing a single tsv.
+type FileWriter struct {
+	Writer
+	filename string
+	f        *os.File
+	zw       *zip.Writer
+}
+
+// Create creates an export file in dir, named for n with the given
+// extension, as with CreateFile.
+func Create(dir string, n *ExportName, ext string) (*FileWriter, error) {
+	name, err := n.Filename(ext)
+	if err != nil {
+		return nil, err
+	}
+	return CreateFile(filepath.Join(dir, name), n.Type, n.Time)
+}
+
+// CreateFile creates an export file with the given path. When the
+// extension is ".zip", the records are written to a tsv of the same
+// name within the zip, as in auto backups, so that the file can be
+// restored by the extension. Otherwise, the file is a bare tsv.
+func CreateFile(filename string, typ ExportType, exportTime time.Time) (*FileWriter, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	fw := &FileWriter{filename: filename, f: f}
+	var w io.Writer = f
+	if ext := filepath.Ext(filename); strings.EqualFold(ext, ".zip") {
+		fw.zw = zip.NewWriter(f)
+		inner := strings.TrimSuffix(filepath.Base(filename), ext) + ".tsv"
+		w, err = fw.zw.CreateHeader(&zip.FileHeader{
+			Name:     inner,
+			Method:   zip.Deflate,
+			Modified: exportTime,
+		})
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	ew, err := NewWriter(w, typ, exportTime)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	fw.Writer = *ew
+	return fw, nil
+}
+
+// Filename returns the path of the file being written.
+func (w *FileWriter) Filename() string { return w.filename }
+
+// Close flushes any buffered data and closes the file.
+func (w *FileWriter) Close() error {
+	err := w.Flush()
+	if w.zw != nil {
+		if err2 := w.zw.Close(); err == nil {
+			err = err2
+		}
+	}
+	if err2 := w.f.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// WriteFile writes the export to a file in dir, named according to its
+// type, backup kind, and export time, and returns the path of the file.
+func (ex *Export) WriteFile(dir, ext string) (string, error) {
+	n := &ExportName{
+		Type:     ex.Type,
+		Backup:   ex.Backup,
+		Time:     ex.ExportTime,
+		WithTime: ex.Type == AnalysisExport,
+	}
+	w, err := Create(dir, n, ext)
+	if err != nil {
+		return "", err
+	}
+	if err := w.WriteAll(ex.Visits); err != nil {
+		w.Close()
+		return "", err
+	}
+	return w.Filename(), w.Close()
+}
+
+// WriteHistoryDB appends the visits of the export to a Chrome History
+// database and returns the number of visits inserted. Visits that are
+// already present are skipped. Visits from analysis exports only have
+// core transition types, unless enriched.
+func (ex *Export) WriteHistoryDB(db *sql.DB) (int, error) {
+	w, err := chrome.NewHistoryWriter(db)
+	if err != nil {
+		return 0, err
+	}
+	for i := range ex.Visits {
+		v := &ex.Visits[i]
+		if err := w.Add(v.URL, v.PageTitle, v.VisitTime, v.Transition); err != nil {
+			w.Rollback()
+			return 0, err
+		}
+	}
+	if err := w.Commit(); err != nil {
+		return 0, err
+	}
+	return w.Inserted, nil
+}
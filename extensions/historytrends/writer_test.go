@@ -0,0 +1,109 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package historytrends
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportNameFilename(t *testing.T) {
+	date := time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name ExportName
+		ext  string
+		want string
+	}{
+		{ExportName{ArchivedExport, FullBackup, date, false}, ".zip", "history_autobackup_20210203_full.zip"},
+		{ExportName{ArchivedExport, IncrementalBackup, date, false}, ".tsv", "history_autobackup_20210203_incremental.tsv"},
+		{ExportName{ArchivedExport, IncrementalBackup, date, false}, ".txt", "history_autobackup_20210203_incremental.txt"},
+		{ExportName{ArchivedExport, NotBackup, date, false}, ".tsv", "exported_archived_history_20210203.tsv"},
+		{ExportName{ArchivedExport, NotBackup, date, false}, ".txt", "exported_archived_history_20210203.txt"},
+		{ExportName{AnalysisExport, NotBackup, date.Add(12*time.Hour + 34*time.Second), true}, ".tsv", "exported_analysis_history_20210203_120034.tsv"},
+		{ExportName{AnalysisExport, NotBackup, date, false}, ".tsv", "exported_analysis_history_20210203.tsv"},
+		{ExportName{AnalysisExport, NotBackup, date, false}, ".txt", "exported_analysis_history_20210203.txt"},
+	}
+	for _, test := range tests {
+		got, err := test.name.Filename(test.ext)
+		if err != nil {
+			t.Errorf("%s: %v", test.want, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+		n, err := ParseExportName(got)
+		if err != nil {
+			t.Errorf("%s: %v", got, err)
+			continue
+		}
+		if *n != test.name {
+			t.Errorf("%s: parsed %v, want %v", got, *n, test.name)
+		}
+	}
+
+	for _, test := range []struct {
+		name ExportName
+		ext  string
+	}{
+		{ExportName{AnalysisExport, FullBackup, date, false}, ".tsv"},
+		{ExportName{ArchivedExport, NotBackup, date, false}, ".csv"},
+	} {
+		if _, err := test.name.Filename(test.ext); err == nil {
+			t.Errorf("%v: expected error", test.name)
+		}
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	r, err := OpenReader(filepath.Join("testdata", "history_autobackup_20210203_full.tsv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	ex, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for _, ext := range []string{".zip", ".tsv"} {
+		filename, err := ex.WriteFile(dir, ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join(dir, "history_autobackup_20210203_full"+ext); filename != want {
+			t.Errorf("got filename %q, want %q", filename, want)
+		}
+		if ext == ".zip" {
+			zr, err := zip.OpenReader(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(zr.File) != 1 || zr.File[0].Name != "history_autobackup_20210203_full.tsv" {
+				t.Errorf("unexpected zip contents: %v", zr.File)
+			}
+			zr.Close()
+		}
+
+		r2, err := OpenReader(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := r2.ReadAll()
+		r2.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, ex) {
+			t.Errorf("%s: got %v, want %v", ext, got, ex)
+		}
+	}
+}
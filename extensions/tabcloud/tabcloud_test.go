@@ -0,0 +1,50 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package tabcloud
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andrewarchi/browser/internal/golden"
+	"github.com/andrewarchi/browser/jsonutil"
+)
+
+func TestParse(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "tabcloud.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	windows, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "tabcloud", windows)
+}
+
+func TestRoundTrip(t *testing.T) {
+	filename := filepath.Join("testdata", "tabcloud.json")
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	windows, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffs, err := jsonutil.Verify(data, &tabCloudResponse{"loggedin", windows})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diffs {
+		t.Errorf("%s: %s", filename, d)
+	}
+}
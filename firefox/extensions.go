@@ -13,28 +13,39 @@ import (
 )
 
 // ExtensionSettings contains preferences and commands set by extensions
-// in extension-settings.json.
+// in extension-settings.json. The file is managed by
+// ExtensionSettingsStore, which is consulted to determine which
+// extension controls each setting.
+//
+// Source:
+// https://searchfox.org/mozilla-central/source/toolkit/components/extensions/ExtensionSettingsStore.jsm
 type ExtensionSettings struct {
-	Version              int                 `json:"version"` // e.g. 2
-	Commands             map[string]Command  `json:"commands"`
-	URLOverrides         jsonutil.UnknownObj `json:"url_overrides"`
-	Prefs                map[string]Pref     `json:"prefs"`
-	DefaultSearch        jsonutil.UnknownObj `json:"default_search"`
-	HomepageNotification jsonutil.UnknownObj `json:"homepageNotification"`
-	TabHideNotification  jsonutil.UnknownObj `json:"tabHideNotification"`
-	NewTabNotification   jsonutil.UnknownObj `json:"newTabNotification"`
+	Version              int                `json:"version"` // e.g. 2
+	Commands             map[string]Command `json:"commands"`
+	URLOverrides         map[string]Pref    `json:"url_overrides"`        // key: e.g. "newTabURL"
+	Prefs                map[string]Pref    `json:"prefs"`                // key: e.g. "homepage_override"
+	DefaultSearch        map[string]Pref    `json:"default_search"`       // key: "defaultSearch", value: engine name
+	HomepageNotification map[string]Pref    `json:"homepageNotification"` // key: extension ID, value: true when confirmed
+	TabHideNotification  map[string]Pref    `json:"tabHideNotification"`  // key: extension ID, value: true when confirmed
+	NewTabNotification   map[string]Pref    `json:"newTabNotification"`   // key: extension ID, value: true when confirmed
 }
 
-// Command is a command with values set by extensions.
+// Command is a command with values set by extensions. Values are of
+// the form {"shortcut": "Ctrl+Shift+Y"}.
 type Command struct {
 	PrecedenceList []ExtensionSetting `json:"precedenceList"`
 }
 
-// Pref is a preference with values set by extensions and an initial
-// value.
+// Pref is a setting with values set by extensions and an initial value.
+// Preferences, URL overrides, the default search engine, and
+// notification confirmations all share this structure. When the user
+// chooses between extensions, as for the default search engine, the
+// choice is recorded in Selected and SelectedDate.
 type Pref struct {
 	InitialValue   interface{}        `json:"initialValue"`
 	PrecedenceList []ExtensionSetting `json:"precedenceList"`
+	Selected       string             `json:"selected,omitempty"`     // ID of the extension selected by the user
+	SelectedDate   *timefmt.UnixMilli `json:"selectedDate,omitempty"` // time of selection
 }
 
 // ExtensionSetting is a value set by an extension.
@@ -45,6 +56,137 @@ type ExtensionSetting struct {
 	Enabled     bool              `json:"enabled"`
 }
 
+// Controller returns the extension setting that is in effect for the
+// command, or nil when no enabled extension sets it.
+func (c *Command) Controller() *ExtensionSetting {
+	return topSetting(c.PrecedenceList)
+}
+
+// Value returns the value in effect for the command, or nil when no
+// enabled extension sets it.
+func (c *Command) Value() interface{} {
+	if s := c.Controller(); s != nil {
+		return s.Value
+	}
+	return nil
+}
+
+// Controller returns the extension setting that is in effect for the
+// pref, or nil when the initial value is in effect. An extension
+// selected by the user takes precedence, while it is enabled, over the
+// extensions installed before the selection was made.
+func (p *Pref) Controller() *ExtensionSetting {
+	top := topSetting(p.PrecedenceList)
+	if s := p.selectedSetting(); s != nil && (top == nil || p.SelectedDate == nil ||
+		!top.InstallDate.After(p.SelectedDate.Time)) {
+		return s
+	}
+	return top
+}
+
+// selectedSetting returns the setting of the extension selected by the
+// user, or nil when none is selected or it is not enabled.
+func (p *Pref) selectedSetting() *ExtensionSetting {
+	if p.Selected == "" {
+		return nil
+	}
+	for i := range p.PrecedenceList {
+		if s := &p.PrecedenceList[i]; s.ID == p.Selected && s.Enabled {
+			return s
+		}
+	}
+	return nil
+}
+
+// Value returns the value in effect for the pref: the value of the
+// controlling extension, if any, otherwise the initial value.
+func (p *Pref) Value() interface{} {
+	if s := p.Controller(); s != nil {
+		return s.Value
+	}
+	return p.InitialValue
+}
+
+// topSetting selects the setting with the highest precedence, as in
+// ExtensionSettingsStore getItem: the most recently installed of the
+// enabled extensions. ExtensionSettingsStore keeps precedenceList
+// sorted by descending installDate, but the order is not relied upon
+// here. Ties keep the order in the list.
+func topSetting(list []ExtensionSetting) *ExtensionSetting {
+	var top *ExtensionSetting
+	for i := range list {
+		s := &list[i]
+		if s.Enabled && (top == nil || s.InstallDate.After(top.InstallDate.Time)) {
+			top = s
+		}
+	}
+	return top
+}
+
+// EffectiveSetting is the value in effect for a setting and the
+// extension that controls it.
+type EffectiveSetting struct {
+	Value        interface{}
+	ControllerID string             // empty when the initial value is in effect
+	Selected     bool               // whether the user selected the controlling extension
+	SelectedDate *timefmt.UnixMilli // time of selection, when selected
+}
+
+// EffectiveSettings contains the values in effect for each setting in
+// extension-settings.json, keyed like ExtensionSettings.
+type EffectiveSettings struct {
+	Commands             map[string]EffectiveSetting
+	URLOverrides         map[string]EffectiveSetting
+	Prefs                map[string]EffectiveSetting
+	DefaultSearch        map[string]EffectiveSetting
+	HomepageNotification map[string]EffectiveSetting
+	TabHideNotification  map[string]EffectiveSetting
+	NewTabNotification   map[string]EffectiveSetting
+}
+
+// Effective resolves the precedence of every setting to determine the
+// values in effect and the extensions that control them.
+func (s *ExtensionSettings) Effective() *EffectiveSettings {
+	var commands map[string]EffectiveSetting
+	if s.Commands != nil {
+		commands = make(map[string]EffectiveSetting, len(s.Commands))
+	}
+	for name, c := range s.Commands {
+		var e EffectiveSetting
+		if top := c.Controller(); top != nil {
+			e = EffectiveSetting{Value: top.Value, ControllerID: top.ID}
+		}
+		commands[name] = e
+	}
+	return &EffectiveSettings{
+		Commands:             commands,
+		URLOverrides:         effectivePrefs(s.URLOverrides),
+		Prefs:                effectivePrefs(s.Prefs),
+		DefaultSearch:        effectivePrefs(s.DefaultSearch),
+		HomepageNotification: effectivePrefs(s.HomepageNotification),
+		TabHideNotification:  effectivePrefs(s.TabHideNotification),
+		NewTabNotification:   effectivePrefs(s.NewTabNotification),
+	}
+}
+
+func effectivePrefs(prefs map[string]Pref) map[string]EffectiveSetting {
+	if prefs == nil {
+		return nil
+	}
+	eff := make(map[string]EffectiveSetting, len(prefs))
+	for key, p := range prefs {
+		e := EffectiveSetting{Value: p.InitialValue}
+		if top := p.Controller(); top != nil {
+			e = EffectiveSetting{Value: top.Value, ControllerID: top.ID}
+			if top.ID == p.Selected {
+				e.Selected, e.SelectedDate = true, p.SelectedDate
+			}
+		}
+		eff[key] = e
+	}
+	return eff
+}
+
 // ParseExtensionSettings parses extension-settings.json in a Firefox
 // profile.
 func ParseExtensionSettings(filename string) (*ExtensionSettings, error) {
@@ -79,7 +221,7 @@ type Extensions struct {
 
 type Addon struct {
 	ID                     *uuid.Firefox          `json:"id"`
-	SyncGUID               *uuid.UUID             `json:"syncGUID"` // "{xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx}"
+	SyncGUID               *uuid.Firefox          `json:"syncGUID"` // "{xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx}"
 	Version                string                 `json:"version"`  // addon version
 	Type                   string                 `json:"type"`     // "extension", "theme", "locale", "dictionary"
 	Loader                 jsonutil.UnknownType   `json:"loader"`
@@ -0,0 +1,157 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package firefox
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrewarchi/browser/jsonutil"
+	"github.com/andrewarchi/browser/jsonutil/timefmt"
+)
+
+const extensionSettingsJSON = `{
+  "version": 2,
+  "commands": {
+    "_execute_browser_action": {
+      "precedenceList": [
+        {"id": "a@example.com", "installDate": 1600000000000, "value": {"shortcut": "Ctrl+Shift+Y"}, "enabled": true}
+      ]
+    }
+  },
+  "url_overrides": {
+    "newTabURL": {
+      "initialValue": "about:newtab",
+      "precedenceList": [
+        {"id": "b@example.com", "installDate": 1600000002000, "value": "moz-extension://b/new.html", "enabled": false},
+        {"id": "a@example.com", "installDate": 1600000001000, "value": "moz-extension://a/new.html", "enabled": true}
+      ]
+    }
+  },
+  "prefs": {
+    "homepage_override": {
+      "initialValue": "about:home",
+      "precedenceList": [
+        {"id": "a@example.com", "installDate": 1600000001000, "value": "https://a.example.com/", "enabled": true},
+        {"id": "c@example.com", "installDate": 1600000003000, "value": "https://c.example.com/", "enabled": true}
+      ]
+    },
+    "websites.hyperlinkAuditingEnabled": {
+      "initialValue": true,
+      "precedenceList": [
+        {"id": "a@example.com", "installDate": 1600000001000, "value": false, "enabled": false}
+      ]
+    }
+  },
+  "default_search": {
+    "defaultSearch": {
+      "initialValue": "Google",
+      "precedenceList": [
+        {"id": "d@example.com", "installDate": 1600000005000, "value": "DuckDuckGo", "enabled": true},
+        {"id": "a@example.com", "installDate": 1600000001000, "value": "Bing", "enabled": true}
+      ],
+      "selected": "a@example.com",
+      "selectedDate": 1600000006000
+    }
+  },
+  "homepageNotification": {
+    "c@example.com": {
+      "initialValue": false,
+      "precedenceList": [
+        {"id": "c@example.com", "installDate": 1600000003000, "value": true, "enabled": true}
+      ]
+    }
+  },
+  "tabHideNotification": {},
+  "newTabNotification": {}
+}`
+
+func TestExtensionSettingsEffective(t *testing.T) {
+	var settings ExtensionSettings
+	if err := jsonutil.Decode(strings.NewReader(extensionSettingsJSON), &settings); err != nil {
+		t.Fatal(err)
+	}
+	eff := settings.Effective()
+
+	tests := []struct {
+		name    string
+		setting EffectiveSetting
+		value   interface{}
+		id      string
+	}{
+		{"newTabURL", eff.URLOverrides["newTabURL"], "moz-extension://a/new.html", "a@example.com"},
+		{"homepage_override", eff.Prefs["homepage_override"], "https://c.example.com/", "c@example.com"},
+		{"websites.hyperlinkAuditingEnabled", eff.Prefs["websites.hyperlinkAuditingEnabled"], true, ""},
+		{"homepageNotification", eff.HomepageNotification["c@example.com"], true, "c@example.com"},
+		{"defaultSearch", eff.DefaultSearch["defaultSearch"], "Bing", "a@example.com"},
+	}
+	for _, test := range tests {
+		if test.setting.Value != test.value || test.setting.ControllerID != test.id {
+			t.Errorf("%s: got %v from %q, want %v from %q", test.name,
+				test.setting.Value, test.setting.ControllerID, test.value, test.id)
+		}
+	}
+
+	cmd := eff.Commands["_execute_browser_action"]
+	if shortcut := cmd.Value.(map[string]interface{})["shortcut"]; shortcut != "Ctrl+Shift+Y" || cmd.ControllerID != "a@example.com" {
+		t.Errorf("command: got %v from %q", shortcut, cmd.ControllerID)
+	}
+	if s := eff.DefaultSearch["defaultSearch"]; !s.Selected || s.SelectedDate == nil {
+		t.Errorf("defaultSearch: got %+v, want selected", s)
+	}
+}
+
+func TestPrefSelected(t *testing.T) {
+	at := func(ms int64) timefmt.UnixMilli {
+		return timefmt.UnixMilli{Time: time.Unix(0, ms*int64(time.Millisecond))}
+	}
+	selectedAt := func(ms int64) *timefmt.UnixMilli {
+		date := at(ms)
+		return &date
+	}
+	list := func(aEnabled bool) []ExtensionSetting {
+		return []ExtensionSetting{
+			{ID: "b@example.com", InstallDate: at(2000), Value: "b", Enabled: true},
+			{ID: "a@example.com", InstallDate: at(1000), Value: "a", Enabled: aEnabled},
+		}
+	}
+	tests := []struct {
+		name string
+		pref Pref
+		id   string
+	}{
+		{"unselected", Pref{PrecedenceList: list(true)}, "b@example.com"},
+		{"selected older", Pref{PrecedenceList: list(true), Selected: "a@example.com", SelectedDate: selectedAt(3000)}, "a@example.com"},
+		{"installed after selection", Pref{PrecedenceList: list(true), Selected: "a@example.com", SelectedDate: selectedAt(1500)}, "b@example.com"},
+		{"selected disabled", Pref{PrecedenceList: list(false), Selected: "a@example.com", SelectedDate: selectedAt(3000)}, "b@example.com"},
+		{"selected removed", Pref{PrecedenceList: list(true), Selected: "c@example.com", SelectedDate: selectedAt(3000)}, "b@example.com"},
+	}
+	for _, test := range tests {
+		if got := test.pref.Controller(); got == nil || got.ID != test.id {
+			t.Errorf("%s: got %+v, want %q", test.name, got, test.id)
+		}
+	}
+}
+
+func TestAddonSyncGUID(t *testing.T) {
+	// Sync GUIDs are braced in extensions.json and must stay braced
+	// when re-encoded.
+	const guid = `"{01234567-89ab-cdef-0123-456789abcdef}"`
+	var a Addon
+	if err := json.Unmarshal([]byte(`{"syncGUID":`+guid+`}`), &a); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(a.SyncGUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != guid {
+		t.Errorf("got %s, want %s", b, guid)
+	}
+}
@@ -7,71 +7,60 @@
 package firefox
 
 import (
-	"errors"
-	"fmt"
-	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/andrewarchi/browser/internal/golden"
 )
 
 func TestParse(t *testing.T) {
-	profilesDir, err := ProfilesDir()
-	if err != nil {
-		t.Fatal(err)
-	}
-	profiles, err := filepath.Glob(filepath.Join(profilesDir, "*"))
-	if err != nil {
-		t.Fatal(err)
+	profile := filepath.Join("testdata", "profile")
+	tests := []struct {
+		name  string
+		parse func() (interface{}, error)
+	}{
+		{"addons", func() (interface{}, error) { return ParseAddons(filepath.Join(profile, "addons.json")) }},
+		{"containers", func() (interface{}, error) { return ParseContainers(filepath.Join(profile, "containers.json")) }},
+		{"extension-preferences", func() (interface{}, error) {
+			return ParseExtensionPreferences(filepath.Join(profile, "extension-preferences.json"))
+		}},
+		{"extension-settings", func() (interface{}, error) {
+			return ParseExtensionSettings(filepath.Join(profile, "extension-settings.json"))
+		}},
+		{"extensions", func() (interface{}, error) { return ParseExtensions(filepath.Join(profile, "extensions.json")) }},
+		{"handlers", func() (interface{}, error) { return ParseHandlers(filepath.Join(profile, "handlers.json")) }},
+		{"times", func() (interface{}, error) { return ParseTimes(filepath.Join(profile, "times.json")) }},
+		{"profiles", func() (interface{}, error) { return ParseProfiles("testdata") }},
+		{"installs", func() (interface{}, error) { return ParseInstalls("testdata") }},
 	}
-	for _, profile := range profiles {
-		fmt.Println(profile)
-		if fi, err := os.Stat(profile); err == nil && !fi.IsDir() {
-			continue
-		}
-
-		addons := filepath.Join(profile, "addons.json")
-		_, err = ParseAddons(addons)
-		checkError(t, addons, err)
-
-		containers := filepath.Join(profile, "containers.json")
-		_, err = ParseContainers(containers)
-		checkError(t, containers, err)
-
-		extensions := filepath.Join(profile, "extensions.json")
-		_, err = ParseExtensions(extensions)
-		checkError(t, extensions, err)
-
-		extensionPreferences := filepath.Join(profile, "extension-preferences.json")
-		_, err = ParseExtensionPreferences(extensionPreferences)
-		checkError(t, extensionPreferences, err)
-
-		extensionSettings := filepath.Join(profile, "extension-settings.json")
-		_, err = ParseExtensionSettings(extensionSettings)
-		checkError(t, extensionSettings, err)
-
-		handlers := filepath.Join(profile, "handlers.json")
-		_, err = ParseHandlers(handlers)
-		checkError(t, handlers, err)
-
-		times := filepath.Join(profile, "times.json")
-		_, err = ParseTimes(times)
-		checkError(t, times, err)
-
-		bookmarkBackups, err := filepath.Glob(filepath.Join(profile, "bookmarkbackups", "bookmarks-*.jsonlz4"))
+	for _, test := range tests {
+		v, err := test.parse()
 		if err != nil {
-			t.Error(err)
+			t.Errorf("%s: %v", test.name, err)
 			continue
 		}
-		for _, bookmarkBackup := range bookmarkBackups {
-			_, err = ParseBookmarkBackup(bookmarkBackup)
-			checkError(t, bookmarkBackup, err)
-		}
+		golden.Check(t, test.name, v)
 	}
 }
 
-func checkError(t *testing.T, filename string, err error) {
-	t.Helper()
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		t.Errorf("%s: %s", filename, err)
+func TestParseBookmarkBackup(t *testing.T) {
+	backups, err := filepath.Glob(filepath.Join("testdata", "profile", "bookmarkbackups", "bookmarks-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) == 0 {
+		t.Fatal("no bookmark backups")
+	}
+	for _, filename := range backups {
+		backup, err := ParseBookmarkBackup(filename)
+		if err != nil {
+			t.Errorf("%s: %v", filename, err)
+			continue
+		}
+		// The backup date is in the local timezone.
+		y, m, d := backup.Date.Date()
+		backup.Date = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		golden.Check(t, filepath.Base(filename), backup)
 	}
 }
@@ -0,0 +1,352 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package firefox
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/andrewarchi/browser/chrome"
+	"github.com/andrewarchi/browser/extensions/historytrends"
+	"github.com/andrewarchi/browser/jsonutil/timefmt"
+	"github.com/andrewarchi/browser/takeout"
+)
+
+// The places.sqlite database is SQLite. No driver is imported by this
+// package, so callers open the database with a driver of their choice,
+// such as github.com/mattn/go-sqlite3. Firefox must not be running
+// while the database is modified.
+//
+// Schema documentation:
+// https://developer.mozilla.org/en-US/docs/Mozilla/Tech/Places/Database
+// Firefox source:
+// https://searchfox.org/mozilla-central/source/toolkit/components/places/nsPlacesTables.h
+
+// PlacesWriter inserts visits into the history of a places.sqlite
+// database within a single transaction.
+//
+// While running, Firefox maintains the derived columns of moz_places
+// and moz_origins with temporary triggers, which do not exist when the
+// database is opened by another program, so PlacesWriter updates
+// visit_count, typed, hidden, and last_visit_date itself. Frecency is
+// not computed; new places get a frecency of -1 and are marked for
+// recalculation, when the schema supports it, so that Firefox computes
+// it on next use.
+//
+// Visits that are already present, by URL and visit time, are skipped,
+// so importing the same history again does not create duplicates.
+type PlacesWriter struct {
+	tx           *sql.Tx
+	recalcPlace  bool // moz_places has recalc_frecency
+	recalcOrigin bool // moz_origins has recalc_frecency
+
+	Inserted   int // visits inserted
+	Duplicates int // visits skipped, because they were already present
+	Skipped    int // visits skipped, because Firefox does not store them
+}
+
+// NewPlacesWriter begins a transaction for inserting visits into db.
+// The schema must be from Firefox 62 or later, which introduced
+// moz_origins.
+func NewPlacesWriter(db *sql.DB) (*PlacesWriter, error) {
+	placeCols, err := tableColumns(db, "moz_places")
+	if err != nil {
+		return nil, err
+	}
+	originCols, err := tableColumns(db, "moz_origins")
+	if err != nil {
+		return nil, err
+	}
+	if !placeCols["url_hash"] || !placeCols["origin_id"] || len(originCols) == 0 {
+		return nil, fmt.Errorf("firefox: places schema is older than Firefox 62")
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("firefox: begin places transaction: %w", err)
+	}
+	return &PlacesWriter{
+		tx:           tx,
+		recalcPlace:  placeCols["recalc_frecency"],
+		recalcOrigin: originCols["recalc_frecency"],
+	}, nil
+}
+
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("firefox: query %s schema: %w", table, err)
+	}
+	defer rows.Close()
+	cols := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("firefox: scan %s schema: %w", table, err)
+		}
+		cols[name] = true
+	}
+	return cols, rows.Err()
+}
+
+// AddHistoryTrends inserts a visit from a History Trends Unlimited
+// export.
+func (w *PlacesWriter) AddHistoryTrends(v *historytrends.Visit) error {
+	return w.Add(v.URL, v.PageTitle, v.VisitTime, v.Transition)
+}
+
+// AddTakeout inserts a visit from Google Takeout browser history.
+func (w *PlacesWriter) AddTakeout(v *takeout.Visit) error {
+	return w.Add(v.URL, v.Title, v.Time.Time, chrome.PageTransition(v.PageTransition))
+}
+
+// Add inserts a visit with the visit type mapped from the transition by
+// VisitTypeFromTransition. Visit times are truncated to microseconds.
+// Visits that Firefox does not store in history, namely embedded visits
+// and URLs with schemes other than http, https, ftp, and file, are
+// skipped.
+func (w *PlacesWriter) Add(rawURL, title string, visitTime time.Time, transition chrome.PageTransition) error {
+	typ, err := VisitTypeFromTransition(transition)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("firefox: %w", err)
+	}
+	if typ == VisitEmbed || !storedSchemes[u.Scheme] {
+		w.Skipped++
+		return nil
+	}
+	visitDate := ToPlacesTime(visitTime)
+
+	placeID, err := w.place(u, rawURL, title)
+	if err != nil {
+		return err
+	}
+	var exists bool
+	if err := w.tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM moz_historyvisits
+		WHERE place_id = ? AND visit_date = ?)`, placeID, visitDate).Scan(&exists); err != nil {
+		return fmt.Errorf("firefox: query visit: %w", err)
+	}
+	if exists {
+		w.Duplicates++
+		return nil
+	}
+	if _, err := w.tx.Exec(`INSERT INTO moz_historyvisits
+		(from_visit, place_id, visit_date, visit_type) VALUES (0, ?, ?, ?)`,
+		placeID, visitDate, typ); err != nil {
+		return fmt.Errorf("firefox: insert visit: %w", err)
+	}
+
+	// Mirror the triggers that Firefox uses to maintain moz_places.
+	counted := 0
+	switch typ {
+	case VisitFramedLink, VisitDownload, VisitReload:
+	default:
+		counted = 1
+	}
+	typed := 0
+	if typ == VisitTyped {
+		typed = 1
+	}
+	hidden := 0
+	if typ == VisitFramedLink {
+		hidden = 1
+	}
+	recalc := ""
+	if w.recalcPlace {
+		recalc = ", recalc_frecency = 1"
+	}
+	if _, err := w.tx.Exec(`UPDATE moz_places SET
+		visit_count = visit_count + ?,
+		typed = MAX(typed, ?),
+		hidden = MIN(hidden, ?),
+		last_visit_date = MAX(IFNULL(last_visit_date, 0), ?)`+recalc+`
+		WHERE id = ?`, counted, typed, hidden, visitDate, placeID); err != nil {
+		return fmt.Errorf("firefox: update place: %w", err)
+	}
+	w.Inserted++
+	return nil
+}
+
+// storedSchemes are the URL schemes that are stored in history.
+var storedSchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+	"ftp":   true,
+	"file":  true,
+}
+
+// place returns the ID of the place for the URL, inserting it, if not
+// present. The title of an existing place is set, if it has none.
+func (w *PlacesWriter) place(u *url.URL, rawURL, title string) (int64, error) {
+	hash := URLHash(rawURL)
+	var id int64
+	var oldTitle sql.NullString
+	err := w.tx.QueryRow(`SELECT id, title FROM moz_places
+		WHERE url_hash = ? AND url = ?`, hash, rawURL).Scan(&id, &oldTitle)
+	if err == nil {
+		if oldTitle.String == "" && title != "" {
+			if _, err := w.tx.Exec(`UPDATE moz_places SET title = ? WHERE id = ?`, title, id); err != nil {
+				return 0, fmt.Errorf("firefox: update place title: %w", err)
+			}
+		}
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("firefox: query place: %w", err)
+	}
+
+	originID, err := w.origin(u)
+	if err != nil {
+		return 0, err
+	}
+	guid, err := newGUID()
+	if err != nil {
+		return 0, err
+	}
+	var nullTitle sql.NullString
+	if title != "" {
+		nullTitle = sql.NullString{String: title, Valid: true}
+	}
+	recalc, recalcVal := "", ""
+	if w.recalcPlace {
+		recalc, recalcVal = ", recalc_frecency", ", 1"
+	}
+	res, err := w.tx.Exec(`INSERT INTO moz_places
+		(url, title, rev_host, visit_count, hidden, typed, frecency, guid, url_hash, origin_id`+recalc+`)
+		VALUES (?, ?, ?, 0, 1, 0, -1, ?, ?, ?`+recalcVal+`)`,
+		rawURL, nullTitle, reverseHost(u.Hostname()), guid, hash, originID)
+	if err != nil {
+		return 0, fmt.Errorf("firefox: insert place: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// origin returns the ID of the origin for the URL, inserting it, if not
+// present.
+func (w *PlacesWriter) origin(u *url.URL) (int64, error) {
+	prefix, host := originKey(u)
+	var id int64
+	err := w.tx.QueryRow(`SELECT id FROM moz_origins
+		WHERE prefix = ? AND host = ?`, prefix, host).Scan(&id)
+	if err == nil {
+		if w.recalcOrigin {
+			if _, err := w.tx.Exec(`UPDATE moz_origins SET recalc_frecency = 1 WHERE id = ?`, id); err != nil {
+				return 0, fmt.Errorf("firefox: update origin: %w", err)
+			}
+		}
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("firefox: query origin: %w", err)
+	}
+	recalc, recalcVal := "", ""
+	if w.recalcOrigin {
+		recalc, recalcVal = ", recalc_frecency", ", 1"
+	}
+	res, err := w.tx.Exec(`INSERT INTO moz_origins (prefix, host, frecency`+recalc+`)
+		VALUES (?, ?, 0`+recalcVal+`)`, prefix, host)
+	if err != nil {
+		return 0, fmt.Errorf("firefox: insert origin: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// originKey returns the prefix and host of the origin for the URL, as
+// in moz_origins. File URLs have the prefix file:/// and no host.
+func originKey(u *url.URL) (prefix, host string) {
+	if strings.EqualFold(u.Scheme, "file") {
+		return "file:///", ""
+	}
+	return u.Scheme + "://", strings.ToLower(u.Host)
+}
+
+// Commit commits the inserted visits.
+func (w *PlacesWriter) Commit() error {
+	if err := w.tx.Commit(); err != nil {
+		return fmt.Errorf("firefox: commit places transaction: %w", err)
+	}
+	return nil
+}
+
+// Rollback discards the inserted visits.
+func (w *PlacesWriter) Rollback() error {
+	return w.tx.Rollback()
+}
+
+// URLHash computes the hash of a URL, as stored in url_hash of
+// moz_places and computed by the hash SQL function in Firefox. The
+// upper 16 bits of the 48-bit hash are from the scheme, so that URLs
+// can be queried by prefix. As in Firefox, the scheme is only searched
+// for in the first 50 bytes.
+func URLHash(rawURL string) int64 {
+	const maxCharsToHash = 1500
+	const maxSchemeLen = 50
+	s := rawURL
+	if len(s) > maxCharsToHash {
+		s = s[:maxCharsToHash]
+	}
+	h := int64(hashString(s))
+	head := rawURL
+	if len(head) > maxSchemeLen {
+		head = head[:maxSchemeLen]
+	}
+	if i := strings.IndexByte(head, ':'); i != -1 {
+		h += int64(hashString(rawURL[:i])&0xFFFF) << 32
+	}
+	return h
+}
+
+// hashString is mozilla::HashString from mfbt/HashFunctions.h.
+func hashString(s string) uint32 {
+	const goldenRatio = 0x9E3779B9
+	var h uint32
+	for i := 0; i < len(s); i++ {
+		h = goldenRatio * ((h<<5 | h>>27) ^ uint32(s[i]))
+	}
+	return h
+}
+
+// reverseHost reverses a hostname and appends a dot, as in rev_host of
+// moz_places.
+func reverseHost(host string) string {
+	b := make([]byte, len(host)+1)
+	for i := 0; i < len(host); i++ {
+		b[len(host)-1-i] = host[i]
+	}
+	b[len(host)] = '.'
+	return strings.ToLower(string(b))
+}
+
+// newGUID generates a random GUID for places: 9 random bytes as 12
+// characters of URL-safe base64.
+func newGUID() (string, error) {
+	var b [9]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("firefox: generate guid: %w", err)
+	}
+	return base64.URLEncoding.EncodeToString(b[:]), nil
+}
+
+// FromPlacesTime converts a time in places.sqlite, which is in
+// microseconds since the Unix epoch, to UTC.
+func FromPlacesTime(t int64) time.Time {
+	return timefmt.FromInt(t, 0, timefmt.Micro, timefmt.Unix)
+}
+
+// ToPlacesTime converts a time to microseconds since the Unix epoch, for
+// places.sqlite.
+func ToPlacesTime(t time.Time) int64 {
+	n, _ := timefmt.ToInt(t, timefmt.Micro, timefmt.Unix)
+	return n
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package firefox
+
+import (
+	"database/sql"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrewarchi/browser/chrome"
+	"github.com/andrewarchi/browser/extensions/historytrends"
+	"github.com/andrewarchi/browser/jsonutil/timefmt"
+	"github.com/andrewarchi/browser/takeout"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// placesSchema is the subset of the places.sqlite schema used by this
+// package.
+const placesSchema = `
+CREATE TABLE moz_origins (id INTEGER PRIMARY KEY, prefix TEXT NOT NULL, host TEXT NOT NULL, frecency INTEGER NOT NULL, recalc_frecency INTEGER NOT NULL DEFAULT 0, UNIQUE (prefix, host));
+CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url LONGVARCHAR, title LONGVARCHAR, rev_host LONGVARCHAR, visit_count INTEGER DEFAULT 0, hidden INTEGER DEFAULT 0 NOT NULL, typed INTEGER DEFAULT 0 NOT NULL, frecency INTEGER DEFAULT -1 NOT NULL, last_visit_date INTEGER, guid TEXT, foreign_count INTEGER DEFAULT 0 NOT NULL, url_hash INTEGER DEFAULT 0 NOT NULL, description TEXT, preview_image_url TEXT, origin_id INTEGER REFERENCES moz_origins(id), recalc_frecency INTEGER NOT NULL DEFAULT 0);
+CREATE TABLE moz_historyvisits (id INTEGER PRIMARY KEY, from_visit INTEGER, place_id INTEGER, visit_date INTEGER, visit_type INTEGER, source INTEGER DEFAULT 0 NOT NULL, triggeringPlaceId INTEGER);
+CREATE UNIQUE INDEX moz_places_guid_uniqueindex ON moz_places (guid);
+CREATE INDEX moz_places_url_hashindex ON moz_places (url_hash);
+CREATE INDEX moz_historyvisits_placedateindex ON moz_historyvisits (place_id, visit_date);
+`
+
+func openTestPlaces(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "places.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(placesSchema); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestPlacesWriter(t *testing.T) {
+	db := openTestPlaces(t)
+	htVisits := []historytrends.Visit{
+		{URL: "https://example.com/", VisitTime: time.Date(2021, 2, 2, 0, 0, 0, 123456789, time.UTC),
+			Transition: chrome.TransitionTyped | chrome.TransitionChainStart | chrome.TransitionChainEnd, PageTitle: "Example Domain"},
+		{URL: "https://example.com/", VisitTime: time.Date(2021, 1, 24, 0, 0, 0, 654321000, time.UTC),
+			Transition: chrome.TransitionReload},
+		{URL: "https://example.org/frame", VisitTime: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+			Transition: chrome.TransitionManualSubframe},
+		{URL: "chrome://settings/", VisitTime: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+			Transition: chrome.TransitionTyped},
+	}
+	takeoutVisits := []takeout.Visit{
+		{URL: "https://example.com/", Title: "Example", Time: timefmt.UnixMicro{Time: time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC)},
+			PageTransition: takeout.PageTransition(chrome.TransitionLink)},
+		{URL: "https://example.org/ad", Time: timefmt.UnixMicro{Time: time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC)},
+			PageTransition: takeout.PageTransition(chrome.TransitionAutoSubframe)},
+	}
+
+	// Importing twice must not create duplicates.
+	for i := 0; i < 2; i++ {
+		w, err := NewPlacesWriter(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range htVisits {
+			if err := w.AddHistoryTrends(&htVisits[j]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for j := range takeoutVisits {
+			if err := w.AddTakeout(&takeoutVisits[j]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		inserted, duplicates := 4, 0
+		if i == 1 {
+			inserted, duplicates = 0, 4
+		}
+		if w.Inserted != inserted || w.Duplicates != duplicates || w.Skipped != 2 {
+			t.Errorf("import %d: got %d inserted, %d duplicates, %d skipped, want %d, %d, 2",
+				i, w.Inserted, w.Duplicates, w.Skipped, inserted, duplicates)
+		}
+	}
+
+	type place struct {
+		URL, Title, RevHost          string
+		VisitCount, Hidden, Typed    int
+		Frecency, LastVisit, URLHash int64
+		Prefix, Host                 string
+		RecalcPlace, RecalcOrigin    int
+	}
+	rows, err := db.Query(`SELECT url, IFNULL(title, ''), rev_host, visit_count, hidden, typed,
+		p.frecency, last_visit_date, url_hash, prefix, host, p.recalc_frecency, o.recalc_frecency
+		FROM moz_places p JOIN moz_origins o ON p.origin_id = o.id ORDER BY p.id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var places []place
+	for rows.Next() {
+		var p place
+		if err := rows.Scan(&p.URL, &p.Title, &p.RevHost, &p.VisitCount, &p.Hidden, &p.Typed,
+			&p.Frecency, &p.LastVisit, &p.URLHash, &p.Prefix, &p.Host, &p.RecalcPlace, &p.RecalcOrigin); err != nil {
+			t.Fatal(err)
+		}
+		places = append(places, p)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []place{
+		{"https://example.com/", "Example Domain", "moc.elpmaxe.", 2, 0, 1,
+			-1, 1612310400000000, URLHash("https://example.com/"), "https://", "example.com", 1, 1},
+		{"https://example.org/frame", "", "gro.elpmaxe.", 0, 1, 0,
+			-1, 1612137600000000, URLHash("https://example.org/frame"), "https://", "example.org", 1, 1},
+	}
+	if !reflect.DeepEqual(places, want) {
+		t.Errorf("got places %v, want %v", places, want)
+	}
+
+	var visitTypes []VisitType
+	rows, err = db.Query(`SELECT visit_type FROM moz_historyvisits ORDER BY visit_date`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var typ VisitType
+		if err := rows.Scan(&typ); err != nil {
+			t.Fatal(err)
+		}
+		visitTypes = append(visitTypes, typ)
+	}
+	if want := []VisitType{VisitReload, VisitFramedLink, VisitTyped, VisitLink}; !reflect.DeepEqual(visitTypes, want) {
+		t.Errorf("got visit types %v, want %v", visitTypes, want)
+	}
+}
+
+func TestOriginKey(t *testing.T) {
+	tests := []struct {
+		url, prefix, host string
+	}{
+		{"https://Example.com:8080/path", "https://", "example.com:8080"},
+		{"http://example.org/", "http://", "example.org"},
+		{"file:///home/user/index.html", "file:///", ""},
+		{"file://localhost/etc/hosts", "file:///", ""},
+	}
+	for _, test := range tests {
+		u, err := url.Parse(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prefix, host := originKey(u); prefix != test.prefix || host != test.host {
+			t.Errorf("originKey(%q) = %q, %q, want %q, %q", test.url, prefix, host, test.prefix, test.host)
+		}
+	}
+}
+
+func TestURLHash(t *testing.T) {
+	h := URLHash("https://example.com/")
+	if prefix := h >> 32; prefix != int64(hashString("https")&0xFFFF) {
+		t.Errorf("got prefix hash %#x, want hash of scheme", prefix)
+	}
+	if h&0xFFFFFFFF != int64(hashString("https://example.com/")) {
+		t.Errorf("got hash %#x, want hash of URL in lower 32 bits", h)
+	}
+	if h := URLHash("no scheme"); h != int64(hashString("no scheme")) {
+		t.Errorf("got hash %#x without scheme, want only hash of string", h)
+	}
+	long := strings.Repeat("a", 50) + ":b"
+	if h := URLHash(long); h != int64(hashString(long)) {
+		t.Errorf("got hash %#x with colon after 50 bytes, want only hash of string", h)
+	}
+
+	// url_hash values of the default bookmarks in a Firefox places.sqlite.
+	tests := []struct {
+		url  string
+		hash int64
+	}{
+		{"https://support.mozilla.org/products/firefox", 47358327123126},
+		{"https://www.mozilla.org/en-US/firefox/central/", 47356370932282},
+	}
+	for _, test := range tests {
+		if h := URLHash(test.url); h != test.hash {
+			t.Errorf("URLHash(%q) = %d, want %d", test.url, h, test.hash)
+		}
+	}
+}
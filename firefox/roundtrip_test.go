@@ -0,0 +1,129 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package firefox
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/andrewarchi/browser/jsonutil"
+)
+
+func TestRoundTrip(t *testing.T) {
+	profile := filepath.Join("testdata", "profile")
+	tests := []struct {
+		filename string
+		parse    func(filename string) (interface{}, error)
+		omitZero []string // fields known to drop zero values
+	}{
+		{"addons.json", func(f string) (interface{}, error) { return ParseAddons(f) }, nil},
+		// Access keys are omitted when empty.
+		{"containers.json", func(f string) (interface{}, error) { return ParseContainers(f) }, []string{
+			"$.identities[].accessKey",
+		}},
+		{"extension-preferences.json", func(f string) (interface{}, error) { return ParseExtensionPreferences(f) }, nil},
+		{"extension-settings.json", func(f string) (interface{}, error) { return ParseExtensionSettings(f) }, nil},
+		// URLs and the maximum version are null when unset and are
+		// decoded as empty strings.
+		{"extensions.json", func(f string) (interface{}, error) { return ParseExtensions(f) }, []string{
+			"$.addons[].aboutURL",
+			"$.addons[].blocklistURL",
+			"$.addons[].iconURL",
+			"$.addons[].releaseNotesURI",
+			"$.addons[].targetApplications[].maxVersion",
+			"$.addons[].updateURL",
+		}},
+		{"handlers.json", func(f string) (interface{}, error) { return ParseHandlers(f) }, nil},
+		// The first use time is null in new profiles and is decoded as
+		// the zero time.
+		{"times.json", func(f string) (interface{}, error) { return ParseTimes(f) }, []string{
+			"$.firstUse",
+		}},
+	}
+	for _, test := range tests {
+		filename := filepath.Join(profile, test.filename)
+		v, err := test.parse(filename)
+		if err != nil {
+			t.Errorf("%s: %v", filename, err)
+			continue
+		}
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRoundTrip(t, filename, data, v, test.omitZero...)
+	}
+
+	backups, err := filepath.Glob(filepath.Join(profile, "bookmarkbackups", "bookmarks-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range backups {
+		backup, err := ParseBookmarkBackup(filename)
+		if err != nil {
+			t.Errorf("%s: %v", filename, err)
+			continue
+		}
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if backup.Compressed {
+			if data, err = jsonutil.DecompressMozLz4(data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		checkRoundTrip(t, filename, data, backup.Bookmarks)
+	}
+}
+
+// checkRoundTrip verifies that v re-encodes to data. Differences are
+// only tolerated for the omitZero fields, which are given as paths
+// with array indexes elided, like $.addons[].iconURL, and only when
+// the value is dropped or re-encoded as a zero value.
+func checkRoundTrip(t *testing.T, filename string, data []byte, v interface{}, omitZero ...string) {
+	t.Helper()
+	diffs, err := jsonutil.Verify(data, v)
+	if err != nil {
+		t.Errorf("%s: %v", filename, err)
+		return
+	}
+	for _, d := range diffs {
+		if isOmittedZero(d, omitZero) {
+			continue
+		}
+		t.Errorf("%s: %s", filename, d)
+	}
+}
+
+var arrayIndex = regexp.MustCompile(`\[\d+\]`)
+
+func isOmittedZero(d jsonutil.Diff, omitZero []string) bool {
+	path := arrayIndex.ReplaceAllString(d.Path, "[]")
+	for _, field := range omitZero {
+		if path != field {
+			continue
+		}
+		switch d.Kind {
+		case jsonutil.Missing:
+			return isZeroJSON(d.Want)
+		case jsonutil.Changed:
+			return string(d.Want) == "null" && isZeroJSON(d.Got)
+		}
+	}
+	return false
+}
+
+func isZeroJSON(data []byte) bool {
+	switch string(data) {
+	case "null", "false", "0", `""`, "[]", "{}":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package firefox
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andrewarchi/browser/chrome"
+)
+
+// Visit types documentation:
+// https://developer.mozilla.org/en-US/docs/Mozilla/Tech/Places/Database#moz_historyvisits
+// Firefox source:
+// https://searchfox.org/mozilla-central/source/toolkit/components/places/nsINavHistoryService.idl
+
+// VisitType is the type of transition of a visit, as stored in
+// visit_type of moz_historyvisits in places.sqlite.
+type VisitType uint8
+
+// Values for VisitType:
+const (
+	VisitLink              VisitType = 1 // followed a link
+	VisitTyped             VisitType = 2 // typed in the address bar or selected a suggestion
+	VisitBookmark          VisitType = 3 // followed a bookmark
+	VisitEmbed             VisitType = 4 // loaded in a frame without user action; not stored
+	VisitRedirectPermanent VisitType = 5 // permanent redirect (HTTP 301)
+	VisitRedirectTemporary VisitType = 6 // temporary redirect (HTTP 302, 303, or 307)
+	VisitDownload          VisitType = 7 // downloaded a file
+	VisitFramedLink        VisitType = 8 // followed a link within a frame
+	VisitReload            VisitType = 9 // reloaded the page
+)
+
+// PageTransition converts a Firefox visit type to the closest Chrome
+// page transition. The mapping is lossy in these cases:
+//
+//   - Both redirect types map to a link with the server redirect
+//     qualifier, so permanence is lost. Chrome records the core type of
+//     the navigation that started the redirect chain, which Firefox
+//     does not, so link is assumed.
+//   - Chrome does not record downloads as visits, so VisitDownload maps
+//     to a link.
+//
+// Typed visits gain the from_address_bar qualifier, because Firefox only
+// records typed visits from the address bar.
+func (typ VisitType) PageTransition() (chrome.PageTransition, error) {
+	switch typ {
+	case VisitLink, VisitDownload:
+		return chrome.TransitionLink, nil
+	case VisitTyped:
+		return chrome.TransitionTyped | chrome.TransitionFromAddressBar, nil
+	case VisitBookmark:
+		return chrome.TransitionAutoBookmark, nil
+	case VisitEmbed:
+		return chrome.TransitionAutoSubframe, nil
+	case VisitRedirectPermanent, VisitRedirectTemporary:
+		return chrome.TransitionLink | chrome.TransitionServerRedirect, nil
+	case VisitFramedLink:
+		return chrome.TransitionManualSubframe, nil
+	case VisitReload:
+		return chrome.TransitionReload, nil
+	default:
+		return 0, fmt.Errorf("firefox: unrecognized visit type: %d", typ)
+	}
+}
+
+// VisitTypeFromTransition converts a Chrome page transition to the
+// closest Firefox visit type. The mapping is lossy in these cases:
+//
+//   - Redirects map to VisitRedirectTemporary, regardless of the core
+//     type, except in subframes, as Chrome does not distinguish
+//     permanent from temporary redirects and the more common temporary
+//     redirect is assumed. Client redirects, such as by meta refresh,
+//     are included.
+//   - Transitions from the omnibox other than typed (generated,
+//     keyword, and keyword_generated) map to VisitTyped.
+//   - Transitions without an equivalent (auto_toplevel and form_submit)
+//     map to VisitLink.
+//   - All other qualifiers, such as forward_back, are dropped.
+//
+// Converting from a visit type and back yields the same visit type,
+// except for VisitRedirectPermanent and VisitDownload.
+func VisitTypeFromTransition(t chrome.PageTransition) (VisitType, error) {
+	core := t.Core()
+	subframe := core == chrome.TransitionAutoSubframe || core == chrome.TransitionManualSubframe
+	if t.IsRedirect() && !subframe {
+		return VisitRedirectTemporary, nil
+	}
+	switch core {
+	case chrome.TransitionLink, chrome.TransitionAutoToplevel, chrome.TransitionFormSubmit:
+		return VisitLink, nil
+	case chrome.TransitionTyped, chrome.TransitionGenerated,
+		chrome.TransitionKeyword, chrome.TransitionKeywordGenerated:
+		return VisitTyped, nil
+	case chrome.TransitionAutoBookmark:
+		return VisitBookmark, nil
+	case chrome.TransitionAutoSubframe:
+		return VisitEmbed, nil
+	case chrome.TransitionManualSubframe:
+		return VisitFramedLink, nil
+	case chrome.TransitionReload:
+		return VisitReload, nil
+	default:
+		return 0, fmt.Errorf("firefox: no visit type for transition %s", t)
+	}
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (typ VisitType) MarshalText() ([]byte, error) {
+	return []byte(typ.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (typ *VisitType) UnmarshalText(data []byte) error {
+	t, err := VisitTypeFromString(string(data))
+	if err != nil {
+		return err
+	}
+	*typ = t
+	return nil
+}
+
+// VisitTypeFromString returns the visit type corresponding to the
+// string, as formatted by String. Names are case-insensitive.
+func VisitTypeFromString(typ string) (VisitType, error) {
+	for t := VisitLink; t <= VisitReload; t++ {
+		if strings.EqualFold(typ, t.String()) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("firefox: unrecognized visit type: %q", typ)
+}
+
+func (typ VisitType) String() string {
+	switch typ {
+	case VisitLink:
+		return "LINK"
+	case VisitTyped:
+		return "TYPED"
+	case VisitBookmark:
+		return "BOOKMARK"
+	case VisitEmbed:
+		return "EMBED"
+	case VisitRedirectPermanent:
+		return "REDIRECT_PERMANENT"
+	case VisitRedirectTemporary:
+		return "REDIRECT_TEMPORARY"
+	case VisitDownload:
+		return "DOWNLOAD"
+	case VisitFramedLink:
+		return "FRAMED_LINK"
+	case VisitReload:
+		return "RELOAD"
+	default:
+		return fmt.Sprintf("VisitType(%d)", uint8(typ))
+	}
+}
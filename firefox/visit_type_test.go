@@ -0,0 +1,74 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package firefox
+
+import (
+	"testing"
+
+	"github.com/andrewarchi/browser/chrome"
+)
+
+func TestVisitTypeRoundTrip(t *testing.T) {
+	tests := []struct {
+		Type       VisitType
+		Transition chrome.PageTransition
+		Back       VisitType
+	}{
+		{VisitLink, chrome.TransitionLink, VisitLink},
+		{VisitTyped, chrome.TransitionTyped | chrome.TransitionFromAddressBar, VisitTyped},
+		{VisitBookmark, chrome.TransitionAutoBookmark, VisitBookmark},
+		{VisitEmbed, chrome.TransitionAutoSubframe, VisitEmbed},
+		{VisitRedirectPermanent, chrome.TransitionLink | chrome.TransitionServerRedirect, VisitRedirectTemporary},
+		{VisitRedirectTemporary, chrome.TransitionLink | chrome.TransitionServerRedirect, VisitRedirectTemporary},
+		{VisitDownload, chrome.TransitionLink, VisitLink},
+		{VisitFramedLink, chrome.TransitionManualSubframe, VisitFramedLink},
+		{VisitReload, chrome.TransitionReload, VisitReload},
+	}
+	for _, tt := range tests {
+		typ, err := tt.Type.PageTransition()
+		if err != nil {
+			t.Errorf("%v: %v", tt.Type, err)
+			continue
+		}
+		if typ != tt.Transition {
+			t.Errorf("%v: got transition %v, want %v", tt.Type, typ, tt.Transition)
+		}
+		back, err := VisitTypeFromTransition(typ)
+		if err != nil {
+			t.Errorf("%v: %v", typ, err)
+		} else if back != tt.Back {
+			t.Errorf("%v: got visit type %v, want %v", typ, back, tt.Back)
+		}
+	}
+}
+
+func TestVisitTypeFromTransition(t *testing.T) {
+	tests := []struct {
+		Transition chrome.PageTransition
+		Type       VisitType
+	}{
+		{chrome.TransitionLink | chrome.TransitionForwardBack, VisitLink},
+		{chrome.TransitionFormSubmit, VisitLink},
+		{chrome.TransitionKeyword, VisitTyped},
+		{chrome.TransitionTyped | chrome.TransitionClientRedirect, VisitRedirectTemporary},
+		{chrome.TransitionManualSubframe | chrome.TransitionServerRedirect, VisitFramedLink},
+	}
+	for _, tt := range tests {
+		typ, err := VisitTypeFromTransition(tt.Transition)
+		if err != nil {
+			t.Errorf("%v: %v", tt.Transition, err)
+		} else if typ != tt.Type {
+			t.Errorf("%v: got %v, want %v", tt.Transition, typ, tt.Type)
+		}
+	}
+	if _, err := VisitTypeFromTransition(11); err == nil {
+		t.Error("unknown core transition: want error")
+	}
+	if _, err := VisitType(10).PageTransition(); err == nil {
+		t.Error("unknown visit type: want error")
+	}
+}
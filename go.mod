@@ -6,6 +6,7 @@ require (
 	github.com/PuerkitoBio/goquery v1.6.1
 	github.com/andrewarchi/archive v0.0.0-20210205094453-9a6f6fa5022b
 	github.com/andybalholm/cascadia v1.2.0 // indirect
+	github.com/mattn/go-sqlite3 v1.14.6
 	github.com/pierrec/lz4/v4 v4.1.3
 	github.com/smartystreets/goconvey v1.6.4 // indirect
 	golang.org/x/net v0.0.0-20210119194325-5f4716e94777
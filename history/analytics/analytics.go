@@ -0,0 +1,211 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package analytics computes browsing trends from history, such as the
+// most visited domains, when browsing happens, and browsing sessions.
+package analytics
+
+import (
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/andrewarchi/browser/chrome"
+	"github.com/andrewarchi/browser/extensions/historytrends"
+	"github.com/andrewarchi/browser/history"
+)
+
+// Stats accumulates statistics over visits. Visits may be added in any
+// order.
+type Stats struct {
+	Visits      int
+	Domains     map[string]int                // visits per domain (eTLD+1); "" for hosts without
+	Heatmap     [7][24]int                    // visits by local weekday, then hour
+	Transitions map[chrome.PageTransition]int // visits per core transition type
+
+	loc      *time.Location
+	idle     time.Duration
+	sessions []span // sessions ordered by time, merged as visits are added
+}
+
+// span is a session with times in Unix nanoseconds.
+type span struct {
+	start, end int64
+	visits     int
+}
+
+// New returns empty Stats. Times of day are computed in loc, such as
+// the location of the export time of an analysis export, which is
+// recovered from its first record. Sessions are split when no visits
+// occur for longer than idle.
+func New(loc *time.Location, idle time.Duration) *Stats {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &Stats{
+		Domains:     make(map[string]int),
+		Transitions: make(map[chrome.PageTransition]int),
+		loc:         loc,
+		idle:        idle,
+	}
+}
+
+// Analyze computes statistics over all visits in src.
+func Analyze(src history.Source, loc *time.Location, idle time.Duration) (*Stats, error) {
+	s := New(loc, idle)
+	if err := s.addAll(src); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Stats) addAll(src history.Source) error {
+	return history.Each(src, func(v *history.Visit) error {
+		s.Add(v)
+		return nil
+	})
+}
+
+// AnalyzeExport computes statistics over the visits in a History Trends
+// Unlimited export that are kept by the filters. Times of day are
+// computed in the timezone of the export, which is known for analysis
+// exports and is otherwise UTC.
+func AnalyzeExport(r *historytrends.Reader, idle time.Duration, filters ...history.Filter) (*Stats, error) {
+	// The timezone is recovered upon reading the first record.
+	first, err := r.Read()
+	if err == io.EOF {
+		return New(r.ExportTime().Location(), idle), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := New(r.ExportTime().Location(), idle)
+	keep := history.And(filters...)
+	if v := history.FromHistoryTrends(first); keep(v) {
+		s.Add(v)
+	}
+	if err := s.addAll(history.Filtered(history.NewHistoryTrendsSource(r), keep)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add adds a visit to the statistics.
+func (s *Stats) Add(v *history.Visit) {
+	s.Visits++
+	_, domain, err := historytrends.HostDomain(v.URL)
+	if err != nil {
+		domain = ""
+	}
+	s.Domains[domain]++
+	local := v.Time.In(s.loc)
+	s.Heatmap[local.Weekday()][local.Hour()]++
+	s.Transitions[v.Transition&chrome.TransitionCoreMask]++
+	s.addSession(v.Time.UnixNano())
+}
+
+// addSession adds a visit time to the session containing it or within
+// the idle duration of it, merging the sessions before and after it
+// when it bridges them, or else starts a new session. Only the bounds
+// of each session are kept, rather than every visit time.
+func (s *Stats) addSession(t int64) {
+	idle := int64(s.idle)
+	i := sort.Search(len(s.sessions), func(i int) bool { return s.sessions[i].end >= t })
+	if i < len(s.sessions) && s.sessions[i].start <= t {
+		s.sessions[i].visits++
+		return
+	}
+	joinPrev := i > 0 && t-s.sessions[i-1].end <= idle
+	joinNext := i < len(s.sessions) && s.sessions[i].start-t <= idle
+	switch {
+	case joinPrev && joinNext:
+		prev := &s.sessions[i-1]
+		prev.end = s.sessions[i].end
+		prev.visits += s.sessions[i].visits + 1
+		s.sessions = append(s.sessions[:i], s.sessions[i+1:]...)
+	case joinPrev:
+		s.sessions[i-1].end = t
+		s.sessions[i-1].visits++
+	case joinNext:
+		s.sessions[i].start = t
+		s.sessions[i].visits++
+	default:
+		s.sessions = append(s.sessions, span{})
+		copy(s.sessions[i+1:], s.sessions[i:])
+		s.sessions[i] = span{t, t, 1}
+	}
+}
+
+// Count is a number of visits for a key.
+type Count struct {
+	Key    string
+	Visits int
+}
+
+// TopDomains returns the n most visited domains, ordered by descending
+// visits, then by domain. When n is negative, all domains are returned.
+func (s *Stats) TopDomains(n int) []Count {
+	counts := make([]Count, 0, len(s.Domains))
+	for domain, visits := range s.Domains {
+		counts = append(counts, Count{domain, visits})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Visits != counts[j].Visits {
+			return counts[i].Visits > counts[j].Visits
+		}
+		return counts[i].Key < counts[j].Key
+	})
+	if n >= 0 && n < len(counts) {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+// TransitionShare returns the fraction of visits with the given core
+// transition type.
+func (s *Stats) TransitionShare(typ chrome.PageTransition) float64 {
+	if s.Visits == 0 {
+		return 0
+	}
+	return float64(s.Transitions[typ&chrome.TransitionCoreMask]) / float64(s.Visits)
+}
+
+// TypedLinkRatio returns the ratio of typed visits to link visits,
+// which indicates how often pages are navigated to directly, rather
+// than by following links. It is NaN when there are no link visits.
+func (s *Stats) TypedLinkRatio() float64 {
+	link := s.Transitions[chrome.TransitionLink]
+	if link == 0 {
+		return math.NaN()
+	}
+	return float64(s.Transitions[chrome.TransitionTyped]) / float64(link)
+}
+
+// Session is a period of continuous browsing.
+type Session struct {
+	Start  time.Time
+	End    time.Time // time of last visit
+	Visits int
+}
+
+// Duration returns the time between the first and last visit.
+func (s Session) Duration() time.Duration { return s.End.Sub(s.Start) }
+
+// Sessions splits the visits into sessions, which end when the gap
+// between consecutive visits exceeds the idle duration. Times are in
+// the location of the statistics.
+func (s *Stats) Sessions() []Session {
+	if len(s.sessions) == 0 {
+		return nil
+	}
+	toTime := func(t int64) time.Time { return time.Unix(0, t).In(s.loc) }
+	sessions := make([]Session, len(s.sessions))
+	for i, sp := range s.sessions {
+		sessions[i] = Session{Start: toTime(sp.start), End: toTime(sp.end), Visits: sp.visits}
+	}
+	return sessions
+}
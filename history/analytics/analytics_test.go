@@ -0,0 +1,92 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package analytics
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andrewarchi/browser/chrome"
+	"github.com/andrewarchi/browser/extensions/historytrends"
+	"github.com/andrewarchi/browser/history"
+)
+
+func TestAnalyzeExport(t *testing.T) {
+	r, err := historytrends.OpenReader(filepath.Join("..", "..", "extensions", "historytrends",
+		"testdata", "exported_analysis_history_20210202_120000.tsv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	s, err := AnalyzeExport(&r.Reader, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Visits != 3 {
+		t.Errorf("got %d visits, want 3", s.Visits)
+	}
+	wantDomains := []Count{{"", 1}, {"example.com", 1}, {"example.org", 1}}
+	if got := s.TopDomains(-1); !reflect.DeepEqual(got, wantDomains) {
+		t.Errorf("got domains %v, want %v", got, wantDomains)
+	}
+	// The export is in EST, so all visits are at 19:00 local time.
+	var want [7][24]int
+	want[time.Saturday][19] = 1
+	want[time.Sunday][19] = 1
+	want[time.Monday][19] = 1
+	if s.Heatmap != want {
+		t.Errorf("got heatmap %v, want %v", s.Heatmap, want)
+	}
+	if got := s.TypedLinkRatio(); got != 1 {
+		t.Errorf("got typed/link ratio %v, want 1", got)
+	}
+	if got := s.TransitionShare(chrome.TransitionReload); math.Abs(got-1.0/3) > 1e-9 {
+		t.Errorf("got reload share %v, want 1/3", got)
+	}
+	if got := len(s.Sessions()); got != 3 {
+		t.Errorf("got %d sessions, want 3", got)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	start := time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC)
+	s := New(time.UTC, 45*time.Minute)
+	// Added out of order, as in exports.
+	for _, offset := range []time.Duration{2 * time.Hour, 50 * time.Minute, 0, 10 * time.Minute} {
+		s.Add(&history.Visit{URL: "https://example.com/", Time: start.Add(offset), Transition: chrome.TransitionTyped})
+	}
+	want := []Session{
+		{start, start.Add(50 * time.Minute), 3},
+		{start.Add(2 * time.Hour), start.Add(2 * time.Hour), 1},
+	}
+	if got := s.Sessions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := s.TopDomains(1); !reflect.DeepEqual(got, []Count{{"example.com", 4}}) {
+		t.Errorf("got %v", got)
+	}
+	if got := s.TypedLinkRatio(); !math.IsNaN(got) {
+		t.Errorf("got typed/link ratio %v, want NaN", got)
+	}
+
+	// A visit between two sessions merges them.
+	s = New(time.UTC, 45*time.Minute)
+	for _, offset := range []time.Duration{0, 80 * time.Minute, 3 * time.Hour, 40 * time.Minute, 80 * time.Minute} {
+		s.Add(&history.Visit{URL: "https://example.com/", Time: start.Add(offset)})
+	}
+	want = []Session{
+		{start, start.Add(80 * time.Minute), 4},
+		{start.Add(3 * time.Hour), start.Add(3 * time.Hour), 1},
+	}
+	if got := s.Sessions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
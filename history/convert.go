@@ -0,0 +1,236 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package history
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source reads visits from a history format. Read returns io.EOF when
+// no visits remain.
+type Source interface {
+	Read() (*Visit, error)
+	Close() error
+}
+
+// Sink writes visits to a history format. Close flushes any buffered
+// visits, but does not close the underlying writer, except for sinks
+// returned by CreateFile.
+type Sink interface {
+	Write(v *Visit) error
+	Close() error
+}
+
+// Format is a history format that can be read, written, or both.
+type Format struct {
+	Name   string // e.g. "historytrends-archived"
+	Fields Field  // fields that can be represented
+
+	// Match reports whether the filename is in this format.
+	Match func(filename string) bool
+	// Open opens a file for reading. It is nil when the format cannot
+	// be read.
+	Open func(filename string) (Source, error)
+	// Create returns a sink that writes to w. The export time is
+	// recorded by formats that include it. It is nil when the format
+	// cannot be written.
+	Create func(w io.Writer, exportTime time.Time) (Sink, error)
+	// CreateFile creates a file and returns a sink that writes to it
+	// and closes it. It is set by formats that depend on the filename,
+	// such as History Trends Unlimited exports, which are zipped when
+	// named .zip. When nil, CreateFile writes to the file with Create.
+	CreateFile func(filename string, exportTime time.Time) (Sink, error)
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]*Format)
+)
+
+// Register makes a format available by name. It panics when a format
+// with the same name is already registered.
+func Register(f *Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	if _, dup := formats[f.Name]; dup {
+		panic("history: Register called twice for format " + f.Name)
+	}
+	formats[f.Name] = f
+}
+
+// Lookup returns the format registered with the given name.
+func Lookup(name string) (*Format, error) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	if f, ok := formats[name]; ok {
+		return f, nil
+	}
+	return nil, fmt.Errorf("history: unknown format %q (have %s)", name, strings.Join(formatNames(), ", "))
+}
+
+// Formats returns the registered formats, sorted by name.
+func Formats() []*Format {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	fs := make([]*Format, 0, len(formats))
+	for _, name := range formatNames() {
+		fs = append(fs, formats[name])
+	}
+	return fs
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Detect returns the readable format matching the filename.
+func Detect(filename string) (*Format, error) {
+	for _, f := range Formats() {
+		if f.Open != nil && f.Match != nil && f.Match(filename) {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("history: unrecognized format: %q", filename)
+}
+
+// Open opens a history file, detecting its format from the filename.
+func Open(filename string) (Source, error) {
+	f, err := Detect(filename)
+	if err != nil {
+		return nil, err
+	}
+	return f.Open(filename)
+}
+
+// CreateFile creates a history file in the given format. Closing the
+// sink closes the file.
+func CreateFile(filename string, f *Format, exportTime time.Time) (Sink, error) {
+	if f.CreateFile != nil {
+		return f.CreateFile(filename, exportTime)
+	}
+	if f.Create == nil {
+		return nil, fmt.Errorf("history: format %s cannot be written", f.Name)
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := f.Create(file, exportTime)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &fileSink{dst, file}, nil
+}
+
+// fileSink is a sink that closes its file.
+type fileSink struct {
+	Sink
+	f *os.File
+}
+
+func (s *fileSink) Close() error {
+	err := s.Sink.Close()
+	if err2 := s.f.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// Report summarizes a conversion.
+type Report struct {
+	Visits int           // number of visits converted
+	Lost   map[Field]int // number of visits with data lost in each field
+}
+
+// Lossless reports whether all data was represented in the sink.
+func (r *Report) Lossless() bool { return len(r.Lost) == 0 }
+
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d visits", r.Visits)
+	var lost []Field
+	for f := range r.Lost {
+		lost = append(lost, f)
+	}
+	sort.Slice(lost, func(i, j int) bool { return lost[i] < lost[j] })
+	for _, f := range lost {
+		fmt.Fprintf(&b, "; %s lost in %d", f, r.Lost[f])
+	}
+	return b.String()
+}
+
+// Convert copies all visits from src to dst, which is in format to, and
+// reports the fields which could not be represented in the sink. The
+// sink is closed, but the source is not.
+func Convert(dst Sink, to *Format, src Source) (*Report, error) {
+	r := &Report{Lost: make(map[Field]int)}
+	for {
+		v, err := src.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return r, err
+		}
+		for _, f := range (v.Populated() &^ to.Fields).Each() {
+			r.Lost[f]++
+		}
+		if err := dst.Write(v); err != nil {
+			return r, err
+		}
+		r.Visits++
+	}
+	return r, dst.Close()
+}
+
+// sliceSource reads visits from a slice.
+type sliceSource struct {
+	visits []Visit
+}
+
+// NewSliceSource returns a Source that reads the given visits.
+func NewSliceSource(visits []Visit) Source {
+	return &sliceSource{visits}
+}
+
+func (s *sliceSource) Read() (*Visit, error) {
+	if len(s.visits) == 0 {
+		return nil, io.EOF
+	}
+	v := &s.visits[0]
+	s.visits = s.visits[1:]
+	return v, nil
+}
+
+func (s *sliceSource) Close() error { return nil }
+
+// ReadAll reads all remaining visits from a source.
+func ReadAll(src Source) ([]Visit, error) {
+	var visits []Visit
+	for {
+		v, err := src.Read()
+		if err == io.EOF {
+			return visits, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		visits = append(visits, *v)
+	}
+}
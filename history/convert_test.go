@@ -0,0 +1,156 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package history
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andrewarchi/browser/extensions/historytrends"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		input  string
+		from   string
+		to     string
+		visits int
+		lost   map[Field]int
+	}{
+		{filepath.Join("..", "takeout", "testdata", "Takeout", "Chrome", "BrowserHistory.json"),
+			"takeout", "historytrends-archived", 3, map[Field]int{FieldFaviconURL: 1, FieldClientID: 3}},
+		{filepath.Join("..", "extensions", "historytrends", "testdata", "exported_archived_history_20210202.tsv"),
+			"historytrends-archived", "takeout", 3, map[Field]int{FieldQualifiers: 1}},
+		{filepath.Join("..", "extensions", "historytrends", "testdata", "exported_archived_history_20210202.tsv"),
+			"historytrends-archived", "historytrends-analysis", 3, map[Field]int{FieldQualifiers: 1}},
+		{filepath.Join("..", "extensions", "historytrends", "testdata", "exported_analysis_history_20210202_120000.tsv"),
+			"historytrends-analysis", "historytrends-archived", 3, map[Field]int{}},
+	}
+	for _, test := range tests {
+		from, err := Detect(test.input)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if from.Name != test.from {
+			t.Errorf("%s: detected %s, want %s", test.input, from.Name, test.from)
+		}
+		to, err := Lookup(test.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		src, err := from.Open(test.input)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		var b bytes.Buffer
+		dst, err := to.Create(&b, time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := Convert(dst, to, src)
+		src.Close()
+		if err != nil {
+			t.Errorf("%s to %s: %v", test.from, test.to, err)
+			continue
+		}
+		if r.Visits != test.visits {
+			t.Errorf("%s to %s: got %d visits, want %d", test.from, test.to, r.Visits, test.visits)
+		}
+		if !reflect.DeepEqual(r.Lost, test.lost) {
+			t.Errorf("%s to %s: got lost %v, want %v", test.from, test.to, r.Lost, test.lost)
+		}
+	}
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	input := filepath.Join("..", "extensions", "historytrends", "testdata", "exported_archived_history_20210202.tsv")
+	r, err := historytrends.OpenReader(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	ex, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	visits := make([]Visit, len(ex.Visits))
+	for i := range ex.Visits {
+		visits[i] = *FromHistoryTrends(&ex.Visits[i])
+	}
+	for i := range visits {
+		if got := ToHistoryTrends(&visits[i]); !reflect.DeepEqual(*got, ex.Visits[i]) {
+			t.Errorf("visit %d: got %v, want %v", i, got, ex.Visits[i])
+		}
+	}
+}
+
+func TestConvertZip(t *testing.T) {
+	input := filepath.Join("..", "extensions", "historytrends", "testdata", "exported_archived_history_20210202.tsv")
+	from, err := Lookup("historytrends-archived")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := from.Open(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "history_autobackup_20210203_full.zip")
+	dst, err := CreateFile(output, from, time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Convert(dst, from, src)
+	src.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := historytrends.OpenReader(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer want.Close()
+	wantEx, err := want.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := historytrends.OpenReader(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Close()
+	gotEx, err := got.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotEx.Visits, wantEx.Visits) {
+		t.Errorf("got %v, want %v", gotEx.Visits, wantEx.Visits)
+	}
+}
+
+func TestFieldString(t *testing.T) {
+	tests := []struct {
+		field Field
+		want  string
+	}{
+		{0, "none"},
+		{FieldURL, "url"},
+		{FieldTransition | FieldQualifiers, "transition|qualifiers"},
+		{FieldClientID | 0x100, "client_id|field(0x100)"},
+	}
+	for _, test := range tests {
+		if got := test.field.String(); got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package history
+
+import (
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/andrewarchi/browser/chrome"
+	"github.com/andrewarchi/browser/extensions/historytrends"
+)
+
+// Filter reports whether a visit should be kept.
+type Filter func(v *Visit) bool
+
+// TimeRange keeps visits in [start, end). A zero start or end leaves
+// that side unbounded.
+func TimeRange(start, end time.Time) Filter {
+	return func(v *Visit) bool {
+		return (start.IsZero() || !v.Time.Before(start)) &&
+			(end.IsZero() || v.Time.Before(end))
+	}
+}
+
+// Host keeps visits to any of the given hostnames.
+func Host(hosts ...string) Filter {
+	set := makeSet(hosts)
+	return func(v *Visit) bool {
+		host, _, err := historytrends.HostDomain(v.URL)
+		return err == nil && set[host]
+	}
+}
+
+// Domain keeps visits to any of the given domains (eTLD+1), such as
+// "example.co.uk", including subdomains.
+func Domain(domains ...string) Filter {
+	set := makeSet(domains)
+	return func(v *Visit) bool {
+		_, domain, err := historytrends.HostDomain(v.URL)
+		return err == nil && domain != "" && set[domain]
+	}
+}
+
+// Transition keeps visits with any of the given core transition types.
+// Qualifiers are ignored.
+func Transition(types ...chrome.PageTransition) Filter {
+	var mask uint32
+	for _, typ := range types {
+		mask |= 1 << (typ & chrome.TransitionCoreMask)
+	}
+	return func(v *Visit) bool {
+		core := v.Transition & chrome.TransitionCoreMask
+		return core < 32 && mask&(1<<core) != 0
+	}
+}
+
+// Title keeps visits with titles matching the regular expression.
+func Title(re *regexp.Regexp) Filter {
+	return func(v *Visit) bool {
+		return re.MatchString(v.Title)
+	}
+}
+
+// And keeps visits kept by all filters.
+func And(filters ...Filter) Filter {
+	return func(v *Visit) bool {
+		for _, f := range filters {
+			if !f(v) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or keeps visits kept by any filter.
+func Or(filters ...Filter) Filter {
+	return func(v *Visit) bool {
+		for _, f := range filters {
+			if f(v) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not keeps visits rejected by f.
+func Not(f Filter) Filter {
+	return func(v *Visit) bool { return !f(v) }
+}
+
+func makeSet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+// filterSource reads the visits from a source that are kept by a filter.
+type filterSource struct {
+	src    Source
+	filter Filter
+}
+
+// Filtered returns a Source that reads only the visits from src kept by
+// all of the filters. Visits are streamed, so memory use does not grow
+// with the size of the source.
+func Filtered(src Source, filters ...Filter) Source {
+	return &filterSource{src, And(filters...)}
+}
+
+func (s *filterSource) Read() (*Visit, error) {
+	for {
+		v, err := s.src.Read()
+		if err != nil {
+			return nil, err
+		}
+		if s.filter(v) {
+			return v, nil
+		}
+	}
+}
+
+func (s *filterSource) Close() error { return s.src.Close() }
+
+// Each calls fn for each remaining visit in src. The visit is only valid
+// for the duration of the call.
+func Each(src Source, fn func(v *Visit) error) error {
+	for {
+		v, err := src.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package history
+
+import (
+	"io"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/andrewarchi/browser/chrome"
+)
+
+func TestFilter(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2021, 2, d, 0, 0, 0, 0, time.UTC) }
+	visits := []Visit{
+		{URL: "https://example.com/", Time: day(1), Title: "Example Domain", Transition: chrome.TransitionLink | chrome.TransitionChainEnd},
+		{URL: "https://www.example.co.uk/news", Time: day(2), Title: "News", Transition: chrome.TransitionTyped},
+		{URL: "https://blog.example.co.uk/", Time: day(3), Title: "Blog", Transition: chrome.TransitionReload},
+		{URL: "http://localhost:8080/", Time: day(4), Transition: chrome.TransitionTyped},
+	}
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []int
+	}{
+		{"time range", TimeRange(day(2), day(4)), []int{1, 2}},
+		{"since", TimeRange(day(3), time.Time{}), []int{2, 3}},
+		{"host", Host("localhost", "example.com"), []int{0, 3}},
+		{"domain", Domain("example.co.uk"), []int{1, 2}},
+		{"transition", Transition(chrome.TransitionTyped, chrome.TransitionLink), []int{0, 1, 3}},
+		{"title", Title(regexp.MustCompile(`(?i)^(news|blog)$`)), []int{1, 2}},
+		{"and", And(Domain("example.co.uk"), Transition(chrome.TransitionTyped)), []int{1}},
+		{"or", Or(Host("localhost"), Title(regexp.MustCompile(`Example`))), []int{0, 3}},
+		{"not", Not(Transition(chrome.TransitionTyped)), []int{0, 2}},
+	}
+	for _, test := range tests {
+		var got []int
+		for i := range visits {
+			if test.filter(&visits[i]) {
+				got = append(got, i)
+			}
+		}
+		if !equalInts(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFiltered(t *testing.T) {
+	for _, filename := range []string{
+		filepath.Join("..", "extensions", "historytrends", "testdata", "exported_archived_history_20210202.tsv"),
+		filepath.Join("..", "takeout", "testdata", "Takeout", "Chrome", "BrowserHistory.json"),
+	} {
+		src, err := Open(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filtered := Filtered(src, Domain("example.org"), Transition(chrome.TransitionTyped))
+		var urls []string
+		err = Each(filtered, func(v *Visit) error {
+			urls = append(urls, v.URL)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s: %v", filename, err)
+		} else if _, err := filtered.Read(); err != io.EOF {
+			t.Errorf("%s: got %v after end, want EOF", filename, err)
+		}
+		filtered.Close()
+		if len(urls) != 1 || urls[0] != "https://example.org/article" {
+			t.Errorf("%s: got %v", filename, urls)
+		}
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package history
+
+import (
+	"io"
+	"time"
+
+	"github.com/andrewarchi/browser/extensions/historytrends"
+)
+
+// History Trends Unlimited archived exports store the full transition
+// integer, but analysis exports only store the core type.
+const (
+	historyTrendsArchivedFields = FieldURL | FieldTime | FieldTitle | FieldTransition | FieldQualifiers
+	historyTrendsAnalysisFields = FieldURL | FieldTime | FieldTitle | FieldTransition
+)
+
+func init() {
+	Register(&Format{
+		Name:       "historytrends-archived",
+		Fields:     historyTrendsArchivedFields,
+		Match:      matchHistoryTrends(historytrends.ArchivedExport),
+		Open:       openHistoryTrends,
+		Create:     createHistoryTrends(historytrends.ArchivedExport),
+		CreateFile: createHistoryTrendsFile(historytrends.ArchivedExport),
+	})
+	Register(&Format{
+		Name:       "historytrends-analysis",
+		Fields:     historyTrendsAnalysisFields,
+		Match:      matchHistoryTrends(historytrends.AnalysisExport),
+		Open:       openHistoryTrends,
+		Create:     createHistoryTrends(historytrends.AnalysisExport),
+		CreateFile: createHistoryTrendsFile(historytrends.AnalysisExport),
+	})
+}
+
+// FromHistoryTrends converts a History Trends Unlimited visit.
+func FromHistoryTrends(v *historytrends.Visit) *Visit {
+	fields := historyTrendsArchivedFields
+	if v.CoreOnly {
+		fields = historyTrendsAnalysisFields
+	}
+	return &Visit{
+		URL:        v.URL,
+		Time:       v.VisitTime,
+		Title:      v.PageTitle,
+		Transition: v.Transition,
+		Fields:     fields,
+	}
+}
+
+// ToHistoryTrends converts a visit to a History Trends Unlimited visit.
+func ToHistoryTrends(v *Visit) *historytrends.Visit {
+	return &historytrends.Visit{
+		URL:        v.URL,
+		VisitTime:  v.Time.UTC(),
+		Transition: v.Transition,
+		PageTitle:  v.Title,
+		CoreOnly:   v.Fields&FieldQualifiers == 0,
+	}
+}
+
+func matchHistoryTrends(typ historytrends.ExportType) func(filename string) bool {
+	return func(filename string) bool {
+		t, _, err := historytrends.ParseExportFilename(filename)
+		return err == nil && t == typ
+	}
+}
+
+type historyTrendsSource struct {
+	r      *historytrends.Reader
+	closer io.Closer
+}
+
+func openHistoryTrends(filename string) (Source, error) {
+	r, err := historytrends.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &historyTrendsSource{&r.Reader, r}, nil
+}
+
+// NewHistoryTrendsSource returns a Source that streams visits from a
+// History Trends Unlimited export reader.
+func NewHistoryTrendsSource(r *historytrends.Reader) Source {
+	return &historyTrendsSource{r, nil}
+}
+
+func (s *historyTrendsSource) Read() (*Visit, error) {
+	v, err := s.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	return FromHistoryTrends(v), nil
+}
+
+func (s *historyTrendsSource) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+type historyTrendsSink struct {
+	w *historytrends.Writer
+}
+
+func createHistoryTrends(typ historytrends.ExportType) func(w io.Writer, exportTime time.Time) (Sink, error) {
+	return func(w io.Writer, exportTime time.Time) (Sink, error) {
+		hw, err := historytrends.NewWriter(w, typ, exportTime)
+		if err != nil {
+			return nil, err
+		}
+		return &historyTrendsSink{hw}, nil
+	}
+}
+
+func (s *historyTrendsSink) Write(v *Visit) error { return s.w.Write(ToHistoryTrends(v)) }
+func (s *historyTrendsSink) Close() error         { return s.w.Flush() }
+
+// historyTrendsFileSink writes an export file, which is zipped when
+// named .zip.
+type historyTrendsFileSink struct {
+	w *historytrends.FileWriter
+}
+
+func createHistoryTrendsFile(typ historytrends.ExportType) func(filename string, exportTime time.Time) (Sink, error) {
+	return func(filename string, exportTime time.Time) (Sink, error) {
+		fw, err := historytrends.CreateFile(filename, typ, exportTime)
+		if err != nil {
+			return nil, err
+		}
+		return &historyTrendsFileSink{fw}, nil
+	}
+}
+
+func (s *historyTrendsFileSink) Write(v *Visit) error { return s.w.Write(ToHistoryTrends(v)) }
+func (s *historyTrendsFileSink) Close() error         { return s.w.Close() }
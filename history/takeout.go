@@ -0,0 +1,157 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package history
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andrewarchi/browser/chrome"
+	"github.com/andrewarchi/browser/jsonutil/timefmt"
+	"github.com/andrewarchi/browser/takeout"
+)
+
+// Takeout only exports the core transition type.
+const takeoutFields = FieldURL | FieldTime | FieldTitle | FieldTransition | FieldFaviconURL | FieldClientID
+
+func init() {
+	Register(&Format{
+		Name:   "takeout",
+		Fields: takeoutFields,
+		Match:  matchTakeout,
+		Open:   openTakeout,
+		Create: createTakeout,
+	})
+}
+
+// FromTakeout converts a Takeout visit.
+func FromTakeout(v *takeout.Visit) *Visit {
+	return &Visit{
+		URL:        v.URL,
+		Time:       v.Time.UTC(),
+		Title:      v.Title,
+		Transition: chrome.PageTransition(v.PageTransition),
+		FaviconURL: v.FaviconURL,
+		ClientID:   v.ClientID,
+		Fields:     takeoutFields,
+	}
+}
+
+// ToTakeout converts a visit to a Takeout visit. Transition qualifiers
+// are dropped.
+func ToTakeout(v *Visit) *takeout.Visit {
+	return &takeout.Visit{
+		FaviconURL:     v.FaviconURL,
+		PageTransition: takeout.PageTransition(v.Transition & chrome.TransitionCoreMask),
+		Title:          v.Title,
+		URL:            v.URL,
+		ClientID:       v.ClientID,
+		Time:           timefmt.UnixMicro{Time: v.Time.UTC()},
+	}
+}
+
+// matchTakeout matches BrowserHistory.json or a Takeout archive.
+func matchTakeout(filename string) bool {
+	base := filepath.Base(filename)
+	return base == "BrowserHistory.json" ||
+		strings.HasPrefix(base, "takeout-") && (strings.HasSuffix(base, ".zip") || strings.HasSuffix(base, ".tgz"))
+}
+
+// openTakeout opens either BrowserHistory.json, which is streamed, or a
+// Takeout export.
+func openTakeout(filename string) (Source, error) {
+	if filepath.Ext(filename) == ".json" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		return &takeoutStreamSource{takeout.NewHistoryReader(f), f}, nil
+	}
+	data, err := takeout.ParseChrome(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &takeoutSource{data.BrowserHistory}, nil
+}
+
+// takeoutSource reads visits from a parsed Takeout export.
+type takeoutSource struct {
+	visits []takeout.Visit
+}
+
+func (s *takeoutSource) Read() (*Visit, error) {
+	if len(s.visits) == 0 {
+		return nil, io.EOF
+	}
+	v := FromTakeout(&s.visits[0])
+	s.visits = s.visits[1:]
+	return v, nil
+}
+
+func (s *takeoutSource) Close() error { return nil }
+
+// takeoutStreamSource streams visits from BrowserHistory.json.
+type takeoutStreamSource struct {
+	r      *takeout.HistoryReader
+	closer io.Closer
+}
+
+// NewTakeoutSource returns a Source that streams visits from a Takeout
+// BrowserHistory.json reader.
+func NewTakeoutSource(r *takeout.HistoryReader) Source {
+	return &takeoutStreamSource{r, nil}
+}
+
+func (s *takeoutStreamSource) Read() (*Visit, error) {
+	v, err := s.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	return FromTakeout(v), nil
+}
+
+func (s *takeoutStreamSource) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// takeoutSink buffers visits, since BrowserHistory.json is a single
+// json object.
+type takeoutSink struct {
+	w      io.Writer
+	visits []takeout.Visit
+}
+
+func createTakeout(w io.Writer, exportTime time.Time) (Sink, error) {
+	return &takeoutSink{w: w}, nil
+}
+
+func (s *takeoutSink) Write(v *Visit) error {
+	s.visits = append(s.visits, *ToTakeout(v))
+	return nil
+}
+
+// Close writes BrowserHistory.json with the indentation used by
+// Takeout.
+func (s *takeoutSink) Close() error {
+	history := struct {
+		BrowserHistory []takeout.Visit `json:"Browser History"`
+	}{s.visits}
+	if history.BrowserHistory == nil {
+		history.BrowserHistory = []takeout.Visit{}
+	}
+	e := json.NewEncoder(s.w)
+	e.SetIndent("", "    ")
+	e.SetEscapeHTML(false)
+	return e.Encode(history)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package history converts browsing history between the formats
+// supported by this module through a common visit model.
+package history
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrewarchi/browser/chrome"
+)
+
+// Visit is a page visit in browsing history, in a form common to all
+// supported formats. Fields records which fields were provided by the
+// source, so that the absence of a value can be distinguished from a
+// zero value, such as the link transition.
+type Visit struct {
+	URL        string
+	Time       time.Time // UTC
+	Title      string
+	Transition chrome.PageTransition
+	FaviconURL string
+	ClientID   []byte // Chrome sync client
+	Fields     Field  // fields provided by the source
+}
+
+// Field is a set of fields in a Visit.
+type Field uint16
+
+// Values for Field:
+const (
+	FieldURL        Field = 1 << iota
+	FieldTime             // visit time
+	FieldTitle            // page title
+	FieldTransition       // core page transition type
+	FieldQualifiers       // page transition qualifiers
+	FieldFaviconURL
+	FieldClientID
+)
+
+var fieldNames = []string{
+	"url",
+	"time",
+	"title",
+	"transition",
+	"qualifiers",
+	"favicon_url",
+	"client_id",
+}
+
+func (f Field) String() string {
+	if f == 0 {
+		return "none"
+	}
+	var names []string
+	for i, name := range fieldNames {
+		if f&(1<<i) != 0 {
+			names = append(names, name)
+			f &^= 1 << i
+		}
+	}
+	if f != 0 {
+		names = append(names, fmt.Sprintf("field(%#x)", uint16(f)))
+	}
+	return strings.Join(names, "|")
+}
+
+// Each returns the individual fields in the set.
+func (f Field) Each() []Field {
+	var fields []Field
+	for bit := Field(1); bit != 0 && bit <= f; bit <<= 1 {
+		if f&bit != 0 {
+			fields = append(fields, bit)
+		}
+	}
+	return fields
+}
+
+// Populated returns the fields provided by the source that hold data.
+// Empty strings, zero times, and transitions without qualifiers do not
+// count, but the core transition always does, since link is zero.
+func (v *Visit) Populated() Field {
+	f := v.Fields
+	if v.URL == "" {
+		f &^= FieldURL
+	}
+	if v.Time.IsZero() {
+		f &^= FieldTime
+	}
+	if v.Title == "" {
+		f &^= FieldTitle
+	}
+	if v.Transition&chrome.TransitionQualifierMask == 0 {
+		f &^= FieldQualifiers
+	}
+	if v.FaviconURL == "" {
+		f &^= FieldFaviconURL
+	}
+	if len(v.ClientID) == 0 {
+		f &^= FieldClientID
+	}
+	return f
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (f Field) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
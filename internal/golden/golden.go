@@ -0,0 +1,65 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package golden compares test results against golden files in
+// testdata/golden. Run tests with -update, or with GOLDEN_UPDATE=1 in
+// the environment, to rewrite the golden files. The flag is only
+// defined in packages that use golden files, so use the environment
+// variable when testing multiple packages, as in ./...
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// updating reports whether golden files should be rewritten.
+func updating() bool {
+	return *update || os.Getenv("GOLDEN_UPDATE") == "1"
+}
+
+// Check formats v as indented json and compares it to the golden file
+// testdata/golden/{name}.json.
+func Check(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+		return
+	}
+	got = append(got, '\n')
+	CheckBytes(t, name+".json", got)
+}
+
+// CheckBytes compares got to the golden file testdata/golden/{name}.
+func CheckBytes(t *testing.T, name string, got []byte) {
+	t.Helper()
+	filename := filepath.Join("testdata", "golden", name)
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Errorf("%s: %v (run with -update to create)", name, err)
+		return
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s: output differs from %s:\n%s", name, filename, got)
+	}
+}
@@ -8,10 +8,12 @@
 package jsonutil
 
 import (
+	"bytes"
 	"encoding"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"strconv"
 )
@@ -21,7 +23,7 @@ import (
 // completion, even on error, so that HTTP response bodies are properly
 // closed and connections can be reused.
 func Decode(r io.Reader, v interface{}) error {
-	return decode(r, v, true, true)
+	return decode(r, v, true, false)
 }
 
 // DecodeAllowUnknownFields decodes the result into data, checking for
@@ -29,7 +31,7 @@ func Decode(r io.Reader, v interface{}) error {
 // that HTTP response bodies are properly closed and connections can be
 // reused.
 func DecodeAllowUnknownFields(r io.Reader, v interface{}) error {
-	return decode(r, v, false, true)
+	return decode(r, v, false, false)
 }
 
 // DecodeFile opens the given file and decodes the result into data,
@@ -53,34 +55,37 @@ func decodeFile(filename string, v interface{}, strict bool) error {
 	return decode(f, v, strict, false)
 }
 
-func decode(r io.Reader, v interface{}, strict, readAll bool) error {
-	d := json.NewDecoder(r)
+// decode decodes a json document into v. When capture is set, values
+// of placeholders are captured, rather than rejected.
+func decode(r io.Reader, v interface{}, strict, capture bool) error {
+	// The document is read fully into memory, so that errors can be
+	// located. json.Decoder buffers the complete value regardless.
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	src := data
+	if capture {
+		// Placeholders can only be found in syntactically valid json;
+		// otherwise, the syntax error is reported below.
+		var raw json.RawMessage
+		if json.NewDecoder(bytes.NewReader(data)).Decode(&raw) == nil {
+			src = markPlaceholders(data, v)
+		}
+	}
+	d := json.NewDecoder(bytes.NewReader(src))
 	if strict {
 		d.DisallowUnknownFields()
 	}
 	if err := d.Decode(v); err != nil {
-		if readAll {
-			_, _ = io.Copy(io.Discard, r)
-		}
-		return err
+		return locateError(data, v, strict, err)
 	}
-
-	br := io.MultiReader(d.Buffered(), r)
-	var buf [4096]byte
-	for {
-		n, err := br.Read(buf[:])
-		if err == io.EOF {
-			return nil
-		}
-		for _, b := range buf[:n] {
-			if !isSpace(b) {
-				if readAll {
-					_, _ = io.Copy(io.Discard, r)
-				}
-				return fmt.Errorf("json: invalid trailing character: %q", b)
-			}
-		}
+	// Only the value is marked, so trailing text is the same in both.
+	off := int(d.InputOffset()) - (len(src) - len(data))
+	if i := skipSpace(data, off); i != len(data) {
+		return &PathError{"$", int64(i), fmt.Errorf("json: invalid trailing character: %q", data[i])}
 	}
+	return nil
 }
 
 func isSpace(c byte) bool {
@@ -0,0 +1,440 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package jsonutil
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PathError records an error and the location in the json document at
+// which it occurred.
+type PathError struct {
+	Path   string // e.g. "$.addons[12].startupData.persistentListeners"
+	Offset int64  // byte offset of the value or key
+	Err    error
+}
+
+func (err *PathError) Error() string {
+	return fmt.Sprintf("%v at %s (offset %d)", err.Err, err.Path, err.Offset)
+}
+
+func (err *PathError) Unwrap() error { return err.Err }
+
+// locateError annotates an error from encoding/json with its location
+// in data. When the location cannot be determined, the error is
+// returned unchanged.
+func locateError(data []byte, v interface{}, strict bool, err error) error {
+	if serr, ok := err.(*json.SyntaxError); ok {
+		return &PathError{pathAtOffset(data, serr.Offset), serr.Offset, err}
+	}
+	// Empty or truncated documents are reported by json.Decoder as EOF,
+	// without a syntax error.
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return err
+	}
+
+	// Since encoding/json does not report the location of most errors,
+	// retrace the decoding of v, mirroring the rules of encoding/json.
+	// The document is syntactically valid, as json.Decoder checks the
+	// syntax before unmarshaling, but the scanners are bounds-checked
+	// regardless.
+	var errs []locatedError
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return err
+	}
+	start := skipSpace(data, 0)
+	l := locator{data: data, strict: strict, report: func(e locatedError) { errs = append(errs, e) }}
+	l.value(start, valueEnd(data, start), t.Elem(), "$")
+
+	var match *locatedError
+	terr, isTypeErr := err.(*json.UnmarshalTypeError)
+	for i := range errs {
+		e := &errs[i]
+		if isTypeErr && e.kind == typeError && e.typ == terr.Type ||
+			!isTypeErr && e.kind != typeError && e.msg == err.Error() {
+			match = e
+			break
+		}
+	}
+	if match == nil {
+		return err
+	}
+	return &PathError{match.path, int64(match.offset), err}
+}
+
+type errorKind uint8
+
+const (
+	typeError errorKind = iota
+	unknownFieldError
+	unmarshalerError
+)
+
+type locatedError struct {
+	kind   errorKind
+	path   string
+	offset int
+	typ    reflect.Type // for typeError
+	msg    string       // for unknownFieldError and unmarshalerError
+}
+
+// locator traverses a syntactically valid json document alongside a
+// Go type and reports values that encoding/json would reject.
+type locator struct {
+	data   []byte
+	strict bool // disallow unknown fields
+	report func(locatedError)
+	// placeholder, when non-nil, is called for each non-null value that
+	// would be unmarshaled into an UnknownObj or UnknownType.
+	placeholder func(start, end int)
+}
+
+var (
+	unmarshalerType     = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func (l *locator) value(start, end int, t reflect.Type, path string) {
+	if start >= end {
+		return
+	}
+	raw := l.data[start:end]
+	isNull := raw[0] == 'n'
+	for t.Kind() == reflect.Ptr {
+		if isNull {
+			return
+		}
+		t = t.Elem()
+	}
+	if l.placeholder != nil && (t == unknownObjType || t == unknownTypeType) {
+		if !isNull {
+			l.placeholder(start, end)
+		}
+		return
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		u := reflect.New(t).Interface().(json.Unmarshaler)
+		if err := u.UnmarshalJSON(raw); err != nil {
+			l.report(locatedError{kind: unmarshalerError, path: path, offset: start, msg: err.Error()})
+		}
+		return
+	}
+	if isNull {
+		return
+	}
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) && t.Kind() != reflect.Map {
+		if raw[0] != '"' {
+			l.typeError(start, t, path)
+			return
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return
+		}
+		u := reflect.New(t).Interface().(encoding.TextUnmarshaler)
+		if err := u.UnmarshalText([]byte(s)); err != nil {
+			l.report(locatedError{kind: unmarshalerError, path: path, offset: start, msg: err.Error()})
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			l.typeError(start, t, path)
+		}
+	case reflect.Struct:
+		if raw[0] != '{' {
+			l.typeError(start, t, path)
+			return
+		}
+		fields := structFields(t)
+		eachMember(l.data, start, func(keyStart int, key string, valStart, valEnd int) {
+			f, ok := fields.lookup(key)
+			if !ok {
+				if l.strict {
+					l.report(locatedError{kind: unknownFieldError, path: path + pathKey(key),
+						offset: keyStart, msg: fmt.Sprintf("json: unknown field %q", key)})
+				}
+				return
+			}
+			l.value(valStart, valEnd, f, path+pathKey(key))
+		})
+	case reflect.Map:
+		if raw[0] != '{' {
+			l.typeError(start, t, path)
+			return
+		}
+		kt := t.Key()
+		eachMember(l.data, start, func(keyStart int, key string, valStart, valEnd int) {
+			if !validMapKey(kt, key) {
+				l.typeError(keyStart, kt, path+pathKey(key))
+				return
+			}
+			l.value(valStart, valEnd, t.Elem(), path+pathKey(key))
+		})
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 && raw[0] == '"' {
+			var s string
+			if err := json.Unmarshal(raw, &s); err == nil {
+				if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+					l.report(locatedError{kind: unmarshalerError, path: path, offset: start, msg: err.Error()})
+				}
+			}
+			return
+		}
+		if raw[0] != '[' {
+			l.typeError(start, t, path)
+			return
+		}
+		eachElement(l.data, start, func(i, valStart, valEnd int) {
+			l.value(valStart, valEnd, t.Elem(), path+"["+strconv.Itoa(i)+"]")
+		})
+	case reflect.String:
+		if raw[0] != '"' {
+			l.typeError(start, t, path)
+		}
+	case reflect.Bool:
+		if raw[0] != 't' && raw[0] != 'f' {
+			l.typeError(start, t, path)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if _, err := strconv.ParseInt(string(raw), 10, t.Bits()); err != nil {
+			l.typeError(start, t, path)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if _, err := strconv.ParseUint(string(raw), 10, t.Bits()); err != nil {
+			l.typeError(start, t, path)
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(string(raw), t.Bits()); err != nil {
+			l.typeError(start, t, path)
+		}
+	default:
+		l.typeError(start, t, path)
+	}
+}
+
+func (l *locator) typeError(offset int, t reflect.Type, path string) {
+	l.report(locatedError{kind: typeError, path: path, offset: offset, typ: t})
+}
+
+// validMapKey reports whether encoding/json accepts the key for a map
+// with the given key type.
+func validMapKey(t reflect.Type, key string) bool {
+	switch {
+	case t.Kind() == reflect.String:
+		return true
+	case reflect.PtrTo(t).Implements(textUnmarshalerType):
+		return reflect.New(t).Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key)) == nil
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err := strconv.ParseInt(key, 10, t.Bits())
+		return err == nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		_, err := strconv.ParseUint(key, 10, t.Bits())
+		return err == nil
+	}
+	return false
+}
+
+type fieldSet map[string]reflect.Type
+
+// structFields lists the json fields of a struct, including those
+// promoted from embedded structs.
+func structFields(t reflect.Type) fieldSet {
+	fields := make(fieldSet)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := jsonFieldName(f)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			for k, v := range structFields(ft) {
+				if _, ok := fields[k]; !ok {
+					fields[k] = v
+				}
+			}
+			continue
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// lookup finds a field by name, preferring an exact match over a
+// case-insensitive match, like encoding/json.
+func (fields fieldSet) lookup(key string) (reflect.Type, bool) {
+	if t, ok := fields[key]; ok {
+		return t, true
+	}
+	for name, t := range fields {
+		if strings.EqualFold(name, key) {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// pathAtOffset returns the json path of the value being read at the
+// given offset, for documents that may be syntactically invalid.
+func pathAtOffset(data []byte, offset int64) string {
+	type frame struct {
+		array   bool
+		index   int
+		key     string
+		wantKey bool
+	}
+	var stack []frame
+	completed := func() {
+		if len(stack) != 0 {
+			top := &stack[len(stack)-1]
+			if top.array {
+				top.index++
+			} else {
+				top.wantKey = true
+			}
+		}
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	for d.InputOffset() < offset {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+		if n := len(stack); n != 0 && !stack[n-1].array && stack[n-1].wantKey {
+			if key, ok := tok.(string); ok {
+				stack[n-1].key = key
+				stack[n-1].wantKey = false
+				continue
+			}
+		}
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, frame{wantKey: true})
+		case json.Delim('['):
+			stack = append(stack, frame{array: true})
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			completed()
+		default:
+			completed()
+		}
+	}
+	var b strings.Builder
+	b.WriteString("$")
+	for _, f := range stack {
+		if f.array {
+			b.WriteString("[" + strconv.Itoa(f.index) + "]")
+		} else if f.key != "" || !f.wantKey {
+			b.WriteString(pathKey(f.key))
+		}
+	}
+	return b.String()
+}
+
+// The following functions scan syntactically valid json. On invalid
+// json, they stop at the end of data, rather than panic.
+
+func skipSpace(data []byte, i int) int {
+	for i < len(data) && isSpace(data[i]) {
+		i++
+	}
+	return i
+}
+
+// valueEnd returns the index after the value starting at i.
+func valueEnd(data []byte, i int) int {
+	if i >= len(data) {
+		return len(data)
+	}
+	switch data[i] {
+	case '"':
+		i++
+		for i < len(data) && data[i] != '"' {
+			if data[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(data) {
+			return len(data)
+		}
+		return i + 1
+	case '{', '[':
+		depth := 0
+		for ; i < len(data); i++ {
+			switch data[i] {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			case '"':
+				i = valueEnd(data, i) - 1
+			}
+		}
+		return len(data)
+	default:
+		for i < len(data) && !isSpace(data[i]) && data[i] != ',' &&
+			data[i] != '}' && data[i] != ']' {
+			i++
+		}
+		return i
+	}
+}
+
+// eachMember calls fn for each member of the object starting at i.
+func eachMember(data []byte, i int, fn func(keyStart int, key string, valStart, valEnd int)) {
+	i = skipSpace(data, i+1)
+	for i < len(data) && data[i] != '}' {
+		keyStart := i
+		keyEnd := valueEnd(data, i)
+		var key string
+		_ = json.Unmarshal(data[keyStart:keyEnd], &key)
+		valStart := skipSpace(data, skipSpace(data, keyEnd)+1) // skip ':'
+		if valStart >= len(data) {
+			return
+		}
+		valEnd := valueEnd(data, valStart)
+		fn(keyStart, key, valStart, valEnd)
+		i = skipSpace(data, valEnd)
+		if i < len(data) && data[i] == ',' {
+			i = skipSpace(data, i+1)
+		}
+	}
+}
+
+// eachElement calls fn for each element of the array starting at i.
+func eachElement(data []byte, i int, fn func(index, valStart, valEnd int)) {
+	i = skipSpace(data, i+1)
+	for n := 0; i < len(data) && data[i] != ']'; n++ {
+		valEnd := valueEnd(data, i)
+		fn(n, i, valEnd)
+		i = skipSpace(data, valEnd)
+		if i < len(data) && data[i] == ',' {
+			i = skipSpace(data, i+1)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package jsonutil
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type pathTestAddon struct {
+	ID          string `json:"id"`
+	Hash        Hex    `json:"hash"`
+	StartupData *struct {
+		PersistentListeners map[string][]int `json:"persistentListeners"`
+	} `json:"startupData"`
+}
+
+type pathTest struct {
+	Addons []pathTestAddon `json:"addons"`
+}
+
+var pathErrorTests = []struct {
+	json   string
+	path   string
+	offset int64
+}{
+	{`{"addons": [{"id": "a"}, {"id": "b", "startupData": {"persistentListeners": {"webRequest": [1, "2"]}}}]}`,
+		"$.addons[1].startupData.persistentListeners.webRequest[1]", 95},
+	{`{"addons": [{"id": "a"}, {"id": "b", "unknown": 1}]}`,
+		"$.addons[1].unknown", 37},
+	{`{"addons": [{"id": "a", "hash": "0g"}]}`,
+		"$.addons[0].hash", 32},
+	{`{"addons": [{"id": 1}]}`,
+		"$.addons[0].id", 19},
+	{`{"addons": [{"id": "a"}]} x`,
+		"$", 26},
+	{`{"addons": [{"id": "a"}, {"id" "b"}]}`,
+		"$.addons[1].id", 32},
+}
+
+func TestDecodeEOF(t *testing.T) {
+	for _, test := range []struct {
+		json string
+		err  error
+	}{
+		{``, io.EOF},
+		{`   `, io.EOF},
+		{`{"addons": [`, io.ErrUnexpectedEOF},
+		{`{"addons": [{"id": "a"}`, io.ErrUnexpectedEOF},
+		{`{"addons": [{"id": "a`, io.ErrUnexpectedEOF},
+	} {
+		var v pathTest
+		if err := Decode(strings.NewReader(test.json), &v); err != test.err {
+			t.Errorf("%q: got error %v, want %v", test.json, err, test.err)
+		}
+	}
+}
+
+func TestScanTruncated(t *testing.T) {
+	// The scanners must not panic on truncated json.
+	for _, data := range []string{`{"a": 1`, `{"a": [1, 2`, `{"a`, `["a`, `{"a":`, `[`} {
+		var v struct{ A []int }
+		l := locator{data: []byte(data), report: func(locatedError) {}}
+		l.value(0, valueEnd(l.data, 0), reflect.TypeOf(v), "$")
+	}
+}
+
+func TestPathError(t *testing.T) {
+	for i, test := range pathErrorTests {
+		var v pathTest
+		err := Decode(strings.NewReader(test.json), &v)
+		var perr *PathError
+		if !errors.As(err, &perr) {
+			t.Errorf("#%d: got error %v, want *PathError", i, err)
+			continue
+		}
+		if perr.Path != test.path || perr.Offset != test.offset {
+			t.Errorf("#%d: got %s at offset %d, want %s at offset %d", i, perr.Path, perr.Offset, test.path, test.offset)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package jsonutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// Diff is a difference between an original json document and the
+// document produced by re-encoding its decoded value.
+type Diff struct {
+	Path string // e.g. "$.addons[12].installDate"
+	Kind DiffKind
+	Want json.RawMessage // value in original; nil when added
+	Got  json.RawMessage // value when re-encoded; nil when missing
+}
+
+// DiffKind is the kind of difference.
+type DiffKind uint8
+
+// Values for DiffKind:
+const (
+	_       DiffKind = iota
+	Missing          // value dropped when re-encoding
+	Added            // value introduced when re-encoding
+	Changed          // value changed when re-encoding
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case Missing:
+		return "missing"
+	case Added:
+		return "added"
+	case Changed:
+		return "changed"
+	default:
+		return fmt.Sprintf("diff(%d)", k)
+	}
+}
+
+func (d Diff) String() string {
+	switch d.Kind {
+	case Missing:
+		return fmt.Sprintf("%s: missing %s", d.Path, d.Want)
+	case Added:
+		return fmt.Sprintf("%s: added %s", d.Path, d.Got)
+	default:
+		return fmt.Sprintf("%s: %s, want %s", d.Path, d.Got, d.Want)
+	}
+}
+
+// RoundTrip strictly decodes data into v, then verifies that no data
+// was lost by re-encoding v and comparing it to data with Verify.
+func RoundTrip(data []byte, v interface{}) ([]Diff, error) {
+	if err := Decode(bytes.NewReader(data), v); err != nil {
+		return nil, err
+	}
+	return Verify(data, v)
+}
+
+// CompareOptions controls which differences are tolerated when
+// comparing json documents.
+type CompareOptions struct {
+	// OmitZero tolerates keys with null, false, 0, "", [], or {} values
+	// that are missing when re-encoded, as for fields with omitempty,
+	// and null values that are re-encoded as zero values, as for fields
+	// that are not pointers. Without it, a parser that drops such values
+	// is reported.
+	OmitZero bool
+}
+
+// Verify re-encodes v, which was decoded from data, and semantically
+// compares the json trees. Object key order and insignificant
+// whitespace are ignored and numbers are compared by exact value, so
+// that 1e3 equals 1000, but 1.5 does not equal 1.50001. Keys with null,
+// false, 0, "", [], or {} values that are only in the re-encoded
+// document are not reported, since fields without omitempty are always
+// encoded. Any value in data that is dropped or changed is reported; to
+// tolerate dropped zero values, use VerifyWithOptions.
+func Verify(data []byte, v interface{}) ([]Diff, error) {
+	return VerifyWithOptions(data, v, CompareOptions{})
+}
+
+// VerifyWithOptions re-encodes v, which was decoded from data, and
+// compares the json trees, like Verify, with the given options.
+func VerifyWithOptions(data []byte, v interface{}, opts CompareOptions) ([]Diff, error) {
+	got, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return CompareJSONWithOptions(data, got, opts)
+}
+
+// CompareJSON semantically compares two json documents, as in Verify.
+func CompareJSON(want, got []byte) ([]Diff, error) {
+	return CompareJSONWithOptions(want, got, CompareOptions{})
+}
+
+// CompareJSONWithOptions semantically compares two json documents, as
+// in Verify, with the given options.
+func CompareJSONWithOptions(want, got []byte, opts CompareOptions) ([]Diff, error) {
+	w, err := decodeTree(want)
+	if err != nil {
+		return nil, err
+	}
+	g, err := decodeTree(got)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []Diff
+	compareTree(w, g, "$", opts, &diffs)
+	return diffs, nil
+}
+
+func decodeTree(data []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func compareTree(want, got interface{}, path string, opts CompareOptions, diffs *[]Diff) {
+	if opts.OmitZero && want == nil && isZeroTree(got) {
+		return
+	}
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			wv, inWant := w[k]
+			gv, inGot := g[k]
+			switch {
+			case !inGot:
+				if !opts.OmitZero || !isZeroTree(wv) {
+					*diffs = append(*diffs, Diff{path + pathKey(k), Missing, marshalTree(wv), nil})
+				}
+			case !inWant:
+				if !isZeroTree(gv) {
+					*diffs = append(*diffs, Diff{path + pathKey(k), Added, nil, marshalTree(gv)})
+				}
+			default:
+				compareTree(wv, gv, path+pathKey(k), opts, diffs)
+			}
+		}
+		return
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(w) || i < len(g); i++ {
+			p := path + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= len(g):
+				*diffs = append(*diffs, Diff{p, Missing, marshalTree(w[i]), nil})
+			case i >= len(w):
+				*diffs = append(*diffs, Diff{p, Added, nil, marshalTree(g[i])})
+			default:
+				compareTree(w[i], g[i], p, opts, diffs)
+			}
+		}
+		return
+	case json.Number:
+		if g, ok := got.(json.Number); ok && equalNumber(w, g) {
+			return
+		}
+	default: // string, bool, nil
+		if want == got {
+			return
+		}
+	}
+	*diffs = append(*diffs, Diff{path, Changed, marshalTree(want), marshalTree(got)})
+}
+
+func equalNumber(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+	var x, y big.Rat
+	if _, ok := x.SetString(string(a)); !ok {
+		return false
+	}
+	if _, ok := y.SetString(string(b)); !ok {
+		return false
+	}
+	return x.Cmp(&y) == 0
+}
+
+func isZeroTree(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case json.Number:
+		return equalNumber(v, "0")
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+func marshalTree(v interface{}) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return json.RawMessage(strconv.Quote(fmt.Sprint(v)))
+	}
+	return b
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package jsonutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+var compareTests = []struct {
+	want, got string
+	omitZero  bool
+	diffs     []Diff
+}{
+	{`{"a": 1000, "b": [1, 2]}`, `{"b":[1,2],"a":1e3}`, false, nil},
+	{`{"a": null, "b": ""}`, `{"a": 0, "c": false}`, true, nil},
+	{`{"a": null, "b": "", "d": false}`, `{"a": 0, "c": false}`, false,
+		[]Diff{
+			{"$.a", Changed, []byte(`null`), []byte(`0`)},
+			{"$.b", Missing, []byte(`""`), nil},
+			{"$.d", Missing, []byte(`false`), nil},
+		}},
+	{`{"t": 1612224000123.456}`, `{"t": 1612224000123}`, false,
+		[]Diff{{"$.t", Changed, []byte(`1612224000123.456`), []byte(`1612224000123`)}}},
+	{`{"a": {"x": 1}, "b": [1, 2]}`, `{"a": {}, "b": [1], "c": "x"}`, false,
+		[]Diff{
+			{"$.a.x", Missing, []byte(`1`), nil},
+			{"$.b[1]", Missing, []byte(`2`), nil},
+			{"$.c", Added, nil, []byte(`"x"`)},
+		}},
+	{`{"s": "LINK"}`, `{"s": "link"}`, false,
+		[]Diff{{"$.s", Changed, []byte(`"LINK"`), []byte(`"link"`)}}},
+}
+
+func TestCompareJSON(t *testing.T) {
+	for i, test := range compareTests {
+		diffs, err := CompareJSONWithOptions([]byte(test.want), []byte(test.got), CompareOptions{OmitZero: test.omitZero})
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(diffs, test.diffs) {
+			t.Errorf("#%d: got %v, want %v", i, diffs, test.diffs)
+		}
+	}
+}
+
+func TestRoundTripDropped(t *testing.T) {
+	var v struct {
+		A float32 `json:"a"`
+		B string  `json:"b,omitempty"`
+	}
+	diffs, err := RoundTrip([]byte(`{"a": 16777217, "b": "x"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || diffs[0].Path != "$.a" || diffs[0].Kind != Changed {
+		t.Errorf("got %v, want precision loss at $.a", diffs)
+	}
+}
@@ -38,14 +38,14 @@ func (t QuotedChrome) MarshalText() ([]byte, error) {
 func (t QuotedChrome) MarshalJSON() ([]byte, error) {
 	var buf []byte
 	buf = append(buf, '"')
-	Append(buf, t.Time, Micro, Windows)
+	buf = Append(buf, t.Time, Micro, Windows)
 	buf = append(buf, '"')
 	return buf, nil
 }
 
 // UnmarshalText implements the encoding.TextUnmarshaler interface.
 func (t *QuotedChrome) UnmarshalText(data []byte) error {
-	t0, err := Parse(string(data), Milli, Windows)
+	t0, err := Parse(string(data), Micro, Windows)
 	if err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@
 package timefmt
 
 import (
+	"bytes"
 	"fmt"
 	"strconv"
 	"strings"
@@ -54,7 +55,9 @@ func FromInt(n, nsec int64, unit Unit, epoch Epoch) time.Time {
 	}
 }
 
-var windowsEpoch = time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC)
+// windowsToUnix is the number of seconds between the Windows and Unix
+// epochs.
+const windowsToUnix = 11644473600
 
 func ToInt(t time.Time, unit Unit, epoch Epoch) (n, nsec int64) {
 	if t.IsZero() {
@@ -62,17 +65,15 @@ func ToInt(t time.Time, unit Unit, epoch Epoch) (n, nsec int64) {
 	}
 	e := int64(exp[unit])
 	e0 := int64(exp[Nano-unit])
+	sec, nsec := t.Unix(), int64(t.Nanosecond())
 	switch epoch {
 	case Unix:
-		sec, nsec := t.Unix(), t.UnixNano()/1e9
-		return sec*e + nsec/e0, nsec % e0
 	case Windows:
-		nsec := int64(t.Sub(windowsEpoch))
-		// TODO sub loses precision on large times
-		return nsec / e0, nsec % e0
+		sec += windowsToUnix
 	default:
 		panic(fmt.Sprintf("illegal epoch: %d", epoch))
 	}
+	return sec*e + nsec/e0, nsec % e0
 }
 
 func Parse(s string, unit Unit, epoch Epoch) (time.Time, error) {
@@ -86,6 +87,10 @@ func Parse(s string, unit Unit, epoch Epoch) (time.Time, error) {
 func splitFrac(num string, unit Unit) (n, nsec int64, err error) {
 	if i := strings.IndexByte(num, '.'); i != -1 {
 		frac := num[i+1:]
+		if len(frac) > int(Nano-unit) {
+			err = fmt.Errorf("timefmt: fraction more precise than nanoseconds: %q", num)
+			return
+		}
 		nsec, err = strconv.ParseInt(frac, 10, 64)
 		if err != nil {
 			return
@@ -109,11 +114,13 @@ func Append(b []byte, t time.Time, unit Unit, epoch Epoch) []byte {
 	n, nsec := ToInt(t, unit, epoch)
 	b = strconv.AppendInt(b, n, 10)
 	if nsec != 0 {
+		// Zero-pad the fraction, then trim trailing zeros.
 		b = append(b, '.')
-		for nsec%10 == 0 {
-			nsec /= 10
+		frac := strconv.AppendInt(nil, nsec, 10)
+		for i := len(frac); i < int(Nano-unit); i++ {
+			b = append(b, '0')
 		}
-		b = strconv.AppendInt(b, nsec, 10)
+		b = append(b, bytes.TrimRight(frac, "0")...)
 	}
 	return b
 }
@@ -137,6 +144,8 @@ func (u Unit) String() string {
 		return "milli"
 	case Micro:
 		return "micro"
+	case Nano:
+		return "nano"
 	default:
 		return fmt.Sprintf("unit(%d)", u)
 	}
@@ -0,0 +1,70 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package timefmt
+
+import (
+	"testing"
+	"time"
+)
+
+var formatTests = []struct {
+	s     string
+	unit  Unit
+	epoch Epoch
+	t     time.Time
+}{
+	{"1384634958041.754", Milli, Unix, time.Date(2013, 11, 16, 20, 49, 18, 41754000, time.UTC)},
+	{"1384634958041.005", Milli, Unix, time.Date(2013, 11, 16, 20, 49, 18, 41005000, time.UTC)},
+	{"1384634958", Sec, Unix, time.Date(2013, 11, 16, 20, 49, 18, 0, time.UTC)},
+	{"1612137600123456", Micro, Unix, time.Date(2021, 2, 1, 0, 0, 0, 123456000, time.UTC)},
+	{"13149893660345543", Micro, Windows, time.Date(2017, 9, 14, 20, 14, 20, 345543000, time.UTC)},
+	{"13255920000000000", Micro, Windows, time.Date(2021, 1, 24, 0, 0, 0, 0, time.UTC)},
+	{"0", Micro, Windows, time.Time{}},
+}
+
+func TestParse(t *testing.T) {
+	for _, test := range formatTests {
+		got, err := Parse(test.s, test.unit, test.epoch)
+		if err != nil {
+			t.Errorf("Parse(%q, %s, %s): %v", test.s, test.unit, test.epoch, err)
+			continue
+		}
+		if !got.Equal(test.t) {
+			t.Errorf("Parse(%q, %s, %s) = %s, want %s", test.s, test.unit, test.epoch, got, test.t)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, test := range formatTests {
+		if got := Format(test.t, test.unit, test.epoch); got != test.s {
+			t.Errorf("Format(%s, %s, %s) = %q, want %q", test.t, test.unit, test.epoch, got, test.s)
+		}
+	}
+}
+
+func TestToInt(t *testing.T) {
+	tests := []struct {
+		t       time.Time
+		unit    Unit
+		epoch   Epoch
+		n, nsec int64
+	}{
+		// Sub-second precision is split into the unit and a remainder.
+		{time.Date(2013, 11, 16, 20, 49, 18, 41754321, time.UTC), Milli, Unix, 1384634958041, 754321},
+		{time.Date(2021, 2, 1, 0, 0, 0, 123456789, time.UTC), Micro, Unix, 1612137600123456, 789},
+		// Times more than 292 years after 1601 overflow a time.Duration.
+		{time.Date(2021, 1, 24, 0, 0, 0, 1, time.UTC), Micro, Windows, 13255920000000000, 1},
+		{time.Date(2500, 1, 1, 0, 0, 0, 0, time.UTC), Sec, Windows, 28369699200, 0},
+	}
+	for _, test := range tests {
+		n, nsec := ToInt(test.t, test.unit, test.epoch)
+		if n != test.n || nsec != test.nsec {
+			t.Errorf("ToInt(%s, %s, %s) = %d, %d, want %d, %d", test.t, test.unit, test.epoch, n, nsec, test.n, test.nsec)
+		}
+	}
+}
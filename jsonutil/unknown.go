@@ -6,36 +6,369 @@
 
 package jsonutil
 
-import "fmt"
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
 
 // UnknownObj represents a json object for which the full type
 // information is not known. Any unmarshal of a value that is not null
 // or {} will raise an error. This is to ensure no data loss until all
-// types have been determined.
-type UnknownObj struct{}
+// types have been determined. DecodeCapture instead captures and
+// reports such values.
+type UnknownObj struct {
+	Raw json.RawMessage // raw json value; nil when never unmarshaled
+}
+
+// UnknownType represents a json value for which the full type
+// information is not known. Any unmarshal of a value that is not null
+// will raise an error. This is to ensure no data loss until all types
+// have been determined. DecodeCapture instead captures and reports such
+// values.
+type UnknownType struct {
+	Raw json.RawMessage // raw json value; nil when never unmarshaled
+}
 
 // UnmarshalJSON implements the json.Unmarshaler interface. Any
-// unmarshal of a value that is not null will raise an error. This is to
-// ensure no data loss until all types have been determined.
+// unmarshal of a value that is not null or {} will raise an error,
+// unless decoded with DecodeCapture.
 func (u *UnknownObj) UnmarshalJSON(data []byte) error {
-	if string(data) == "null" || string(data) == "{}" {
+	if raw, ok := captured(data); ok {
+		u.Raw = raw
+		return nil
+	}
+	u.Raw = append(u.Raw[:0], data...)
+	if u.unknown() == nil {
 		return nil
 	}
 	return fmt.Errorf("jsonutil: unmarshal of unknown object type: %q", data)
 }
 
-// UnknownType represents a json value for which the full type
-// information is not known. Any unmarshal of a value that is not null
-// will raise an error. This is to ensure no data loss until all types
-// have been determined.
-type UnknownType struct{}
+// MarshalJSON implements the json.Marshaler interface. The captured
+// value is written as-is.
+func (u UnknownObj) MarshalJSON() ([]byte, error) {
+	if u.Raw == nil {
+		return []byte("{}"), nil
+	}
+	return u.Raw, nil
+}
 
 // UnmarshalJSON implements the json.Unmarshaler interface. Any
-// unmarshal of a value that is not null will raise an error. This is to
-// ensure no data loss until all types have been determined.
+// unmarshal of a value that is not null will raise an error, unless
+// decoded with DecodeCapture.
 func (u *UnknownType) UnmarshalJSON(data []byte) error {
-	if string(data) == "null" {
+	if raw, ok := captured(data); ok {
+		u.Raw = raw
+		return nil
+	}
+	u.Raw = append(u.Raw[:0], data...)
+	if u.unknown() == nil {
 		return nil
 	}
 	return fmt.Errorf("jsonutil: unmarshal of unknown type: %q", data)
 }
+
+// MarshalJSON implements the json.Marshaler interface. The captured
+// value is written as-is.
+func (u UnknownType) MarshalJSON() ([]byte, error) {
+	if u.Raw == nil {
+		return []byte("null"), nil
+	}
+	return u.Raw, nil
+}
+
+// unknown returns the captured value, when it would cause data loss.
+func (u *UnknownObj) unknown() json.RawMessage {
+	data := bytes.TrimSpace(u.Raw)
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	if data[0] == '{' && len(bytes.TrimSpace(data[1:len(data)-1])) == 0 {
+		return nil
+	}
+	return u.Raw
+}
+
+// unknown returns the captured value, when it would cause data loss.
+func (u *UnknownType) unknown() json.RawMessage {
+	data := bytes.TrimSpace(u.Raw)
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	return u.Raw
+}
+
+// UnknownReport lists the values captured by UnknownObj and UnknownType
+// placeholders within a decoded value.
+type UnknownReport struct {
+	Fields []UnknownField // sorted by path
+}
+
+// UnknownField is a placeholder field that captured at least one
+// value. Array indices in the path are generalized to [*] and map keys
+// to *, so that all elements share a single entry.
+type UnknownField struct {
+	Path       string          // e.g. "$.addons[*].loader"
+	Count      int             // number of values captured
+	SamplePath string          // e.g. "$.addons[12].loader"
+	Sample     json.RawMessage // first value captured
+}
+
+// DecodeCapture decodes the result into data, like Decode, but values
+// in UnknownObj and UnknownType placeholders are captured and reported,
+// rather than rejected. The reader is read to completion, even on
+// error.
+func DecodeCapture(r io.Reader, v interface{}) (*UnknownReport, error) {
+	if err := decode(r, v, true, true); err != nil {
+		return nil, err
+	}
+	return ReportUnknown(v), nil
+}
+
+// DecodeFileCapture opens the given file and decodes the result into
+// data, like DecodeFile, but values in UnknownObj and UnknownType
+// placeholders are captured and reported, rather than rejected.
+func DecodeFileCapture(filename string, v interface{}) (*UnknownReport, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := decode(f, v, true, true); err != nil {
+		return nil, err
+	}
+	return ReportUnknown(v), nil
+}
+
+// captureMarker prefixes the placeholder values in a document decoded
+// by DecodeCapture, which are replaced by strings, so that placeholders
+// capture them, rather than raising an error. The random suffix ensures
+// that a document cannot forge a capture.
+var captureMarker = func() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return "\x00jsonutil-capture-" + hex.EncodeToString(b[:]) + ":"
+}()
+
+// markPlaceholders replaces the non-null values in data that would be
+// unmarshaled into placeholders within v with marked strings. data must
+// be a syntactically valid json value, optionally followed by trailing
+// text.
+func markPlaceholders(data []byte, v interface{}) []byte {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Ptr || !hasUnknown(t) {
+		return data
+	}
+	var spans [][2]int
+	l := locator{data: data, report: func(locatedError) {}, placeholder: func(start, end int) {
+		spans = append(spans, [2]int{start, end})
+	}}
+	start := skipSpace(data, 0)
+	l.value(start, valueEnd(data, start), t.Elem(), "$")
+	if len(spans) == 0 {
+		return data
+	}
+	marked := make([]byte, 0, len(data))
+	prev := 0
+	for _, span := range spans {
+		s, _ := json.Marshal(captureMarker + string(data[span[0]:span[1]]))
+		marked = append(append(marked, data[prev:span[0]]...), s...)
+		prev = span[1]
+	}
+	return append(marked, data[prev:]...)
+}
+
+// captured returns the value of a placeholder that was marked by
+// markPlaceholders.
+func captured(data []byte) (json.RawMessage, bool) {
+	if len(data) == 0 || data[0] != '"' {
+		return nil, false
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil || !strings.HasPrefix(s, captureMarker) {
+		return nil, false
+	}
+	return json.RawMessage(s[len(captureMarker):]), true
+}
+
+// ReportUnknown traverses v and reports all values captured by
+// UnknownObj and UnknownType placeholders.
+func ReportUnknown(v interface{}) *UnknownReport {
+	var r UnknownReport
+	index := make(map[string]int)
+	walkUnknown(reflect.ValueOf(v), "$", "$", func(path, general string, raw json.RawMessage) {
+		if i, ok := index[general]; ok {
+			r.Fields[i].Count++
+			return
+		}
+		index[general] = len(r.Fields)
+		r.Fields = append(r.Fields, UnknownField{general, 1, path, raw})
+	})
+	sort.SliceStable(r.Fields, func(i, j int) bool {
+		return r.Fields[i].Path < r.Fields[j].Path
+	})
+	return &r
+}
+
+func (r *UnknownReport) String() string {
+	var b strings.Builder
+	for _, f := range r.Fields {
+		fmt.Fprintf(&b, "%s\t%d\t%s\t%s\n", f.Path, f.Count, f.SamplePath, f.Sample)
+	}
+	return b.String()
+}
+
+var (
+	unknownObjType  = reflect.TypeOf(UnknownObj{})
+	unknownTypeType = reflect.TypeOf(UnknownType{})
+)
+
+// walkUnknown traverses v, calling fn for each captured value with the
+// concrete and generalized json paths.
+func walkUnknown(v reflect.Value, path, general string, fn func(path, general string, raw json.RawMessage)) {
+	if !v.IsValid() || !hasUnknown(v.Type()) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		walkUnknown(v.Elem(), path, general, fn)
+	case reflect.Struct:
+		t := v.Type()
+		if t == unknownObjType || t == unknownTypeType {
+			var raw json.RawMessage
+			if t == unknownObjType {
+				raw = (&UnknownObj{v.Field(0).Bytes()}).unknown()
+			} else {
+				raw = (&UnknownType{v.Field(0).Bytes()}).unknown()
+			}
+			if raw != nil {
+				fn(path, general, raw)
+			}
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			name, ok := jsonFieldName(t.Field(i))
+			if !ok {
+				continue
+			}
+			if name == "" { // embedded struct
+				walkUnknown(v.Field(i), path, general, fn)
+				continue
+			}
+			seg := pathKey(name)
+			walkUnknown(v.Field(i), path+seg, general+seg, fn)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkUnknown(v.Index(i), path+"["+strconv.Itoa(i)+"]", general+"[*]", fn)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = fmt.Sprint(k.Interface())
+		}
+		order := make([]int, len(keys))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(i, j int) bool { return names[order[i]] < names[order[j]] })
+		for _, i := range order {
+			walkUnknown(v.MapIndex(keys[i]), path+pathKey(names[i]), general+".*", fn)
+		}
+	}
+}
+
+// jsonFieldName returns the json name of a struct field, as used by
+// encoding/json. The name is empty for embedded structs without a name
+// in the tag, whose fields are promoted.
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name := tag
+	if i := strings.IndexByte(tag, ','); i != -1 {
+		name = tag[:i]
+	}
+	if f.Anonymous && name == "" {
+		t := f.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			return "", true
+		}
+	}
+	if f.PkgPath != "" { // unexported
+		return "", false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
+
+// pathKey formats an object key as a json path segment.
+func pathKey(key string) string {
+	if key == "" {
+		return `[""]`
+	}
+	for i, c := range key {
+		if !(c == '_' || c == '$' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i != 0 && '0' <= c && c <= '9') {
+			return "[" + strconv.Quote(key) + "]"
+		}
+	}
+	return "." + key
+}
+
+var unknownTypes sync.Map // map[reflect.Type]bool
+
+// hasUnknown reports whether values of type t can contain a
+// placeholder. Interface values are not considered, as encoding/json
+// never decodes into placeholders through an interface.
+func hasUnknown(t reflect.Type) bool {
+	if has, ok := unknownTypes.Load(t); ok {
+		return has.(bool)
+	}
+	has := reachesUnknown(t, make(map[reflect.Type]bool))
+	unknownTypes.Store(t, has)
+	return has
+}
+
+func reachesUnknown(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return reachesUnknown(t.Elem(), seen)
+	case reflect.Struct:
+		if t == unknownObjType || t == unknownTypeType {
+			return true
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if _, ok := jsonFieldName(f); ok && reachesUnknown(f.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
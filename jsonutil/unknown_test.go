@@ -0,0 +1,68 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package jsonutil
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type unknownTest struct {
+	Items []struct {
+		Loader    UnknownType            `json:"loader"`
+		Platforms []UnknownType          `json:"targetPlatforms"`
+		Extra     map[string]*UnknownObj `json:"extra,omitempty"`
+	} `json:"items"`
+}
+
+const unknownJSON = `{"items": [
+  {"loader": null, "targetPlatforms": []},
+  {"loader": "a", "targetPlatforms": [{"os": "linux"}, {"os": "winnt"}]},
+  {"loader": null, "targetPlatforms": [], "extra": {"x-y": {}, "z": {"k": 1}}},
+  {"loader": 42, "targetPlatforms": []}
+]}`
+
+func TestDecodeCapture(t *testing.T) {
+	var v unknownTest
+	report, err := DecodeCapture(strings.NewReader(unknownJSON), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []UnknownField{
+		{"$.items[*].extra.*", 1, "$.items[2].extra.z", []byte(`{"k": 1}`)},
+		{"$.items[*].loader", 2, "$.items[1].loader", []byte(`"a"`)},
+		{"$.items[*].targetPlatforms[*]", 2, "$.items[1].targetPlatforms[0]", []byte(`{"os": "linux"}`)},
+	}
+	if !reflect.DeepEqual(report.Fields, want) {
+		t.Errorf("got report:\n%s", report)
+	}
+}
+
+func TestDecodeUnknownError(t *testing.T) {
+	var v unknownTest
+	err := Decode(strings.NewReader(unknownJSON), &v)
+	var perr *PathError
+	if !errors.As(err, &perr) {
+		t.Fatalf("got error %v, want *PathError", err)
+	}
+	const msg = `jsonutil: unmarshal of unknown type: "\"a\"" at $.items[1].loader (offset 68)`
+	if err.Error() != msg {
+		t.Errorf("got error %q, want %q", err, msg)
+	}
+
+	if err := Decode(strings.NewReader(`{"items": [{"loader": null, "targetPlatforms": []}]}`), &v); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Placeholders reject unknown values without jsonutil too.
+	if err := json.Unmarshal([]byte(unknownJSON), &v); err == nil {
+		t.Error("encoding/json: want error")
+	}
+}
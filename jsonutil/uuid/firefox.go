@@ -13,7 +13,7 @@ import (
 )
 
 // Firefox is an ID or UUID and is used by Firefox addons. ID is
-// preferred for display.
+// preferred for display. UUIDs are formatted with braces.
 type Firefox struct {
 	ID   string // e.g. "addon@example.com"
 	UUID *UUID  // e.g. "{xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx}"
@@ -25,7 +25,7 @@ func (id Firefox) MarshalText() ([]byte, error) {
 		return []byte(id.ID), nil
 	}
 	if id.UUID != nil {
-		return id.UUID.MarshalText()
+		return id.UUID.Encode(Braced), nil
 	}
 	return nil, nil
 }
@@ -52,7 +52,7 @@ func (id Firefox) MarshalJSON() ([]byte, error) {
 		return []byte(strconv.Quote(id.ID)), nil
 	}
 	if id.UUID != nil {
-		return id.UUID.MarshalJSON()
+		return []byte(strconv.Quote(string(id.UUID.Encode(Braced)))), nil
 	}
 	return []byte("null"), nil
 }
@@ -0,0 +1,370 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andrewarchi/archive"
+	"github.com/andrewarchi/browser/chrome"
+	"github.com/andrewarchi/browser/jsonutil"
+	"github.com/andrewarchi/browser/jsonutil/timefmt"
+	"golang.org/x/net/html"
+)
+
+// My Activity is exported per product in
+// Takeout/My Activity/{product}/MyActivity.{json|html}, as JSON in newer
+// exports and HTML in older exports or when selected. Both formats
+// contain the same activity, except that HTML omits activity controls.
+
+// Activity is an item in My Activity, such as a visited page or search.
+type Activity struct {
+	Header           string             `json:"header"` // e.g. "Chrome", "Search"
+	Title            string             `json:"title"`  // e.g. "Visited Example Domain"
+	TitleURL         string             `json:"titleUrl,omitempty"`
+	Subtitles        []ActivitySubtitle `json:"subtitles,omitempty"`
+	Description      string             `json:"description,omitempty"`
+	Time             time.Time          `json:"time"`
+	Products         []string           `json:"products"`
+	Details          []ActivityDetail   `json:"details,omitempty"`
+	ActivityControls []string           `json:"activityControls,omitempty"` // e.g. "Web & App Activity"
+	LocationInfos    []LocationInfo     `json:"locationInfos,omitempty"`
+}
+
+// ActivitySubtitle is a line below the title, such as a YouTube
+// channel.
+type ActivitySubtitle struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// ActivityDetail is a note on an activity, such as "From Google Ads".
+type ActivityDetail struct {
+	Name string `json:"name"`
+}
+
+// LocationInfo is the location of the device at the time of an
+// activity.
+type LocationInfo struct {
+	Name      string `json:"name"`
+	URL       string `json:"url,omitempty"`
+	Source    string `json:"source,omitempty"`
+	SourceURL string `json:"sourceUrl,omitempty"`
+}
+
+// ParseActivity parses activity in MyActivity.json.
+func ParseActivity(r io.Reader) ([]Activity, error) {
+	var activity []Activity
+	if err := jsonutil.Decode(r, &activity); err != nil {
+		return nil, fmt.Errorf("takeout: my activity: %w", err)
+	}
+	return activity, nil
+}
+
+// activityTimeLayout is the format of times in MyActivity.html, which is
+// in the timezone of the user at the time of export.
+const activityTimeLayout = "Jan 2, 2006, 3:04:05 PM MST"
+
+// ParseActivityHTML parses activity in MyActivity.html. Times are only
+// formatted with a timezone abbreviation, so loc is the timezone of the
+// export and abbreviations other than UTC that are not in loc are
+// rejected. Times only have second precision.
+func ParseActivityHTML(r io.Reader, loc *time.Location) ([]Activity, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	var activity []Activity
+	doc.Find("div.outer-cell").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		var a *Activity
+		a, err = parseActivityCell(s, loc)
+		if err != nil {
+			err = fmt.Errorf("takeout: my activity item %d: %w", i+1, err)
+			return false
+		}
+		activity = append(activity, *a)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+func parseActivityCell(s *goquery.Selection, loc *time.Location) (*Activity, error) {
+	header := s.Find("div.header-cell")
+	content := s.Find("div.content-cell.mdl-cell--6-col").Not(".mdl-typography--text-right")
+	caption := s.Find("div.content-cell.mdl-typography--caption")
+	if header.Length() != 1 || content.Length() != 1 || caption.Length() > 1 {
+		return nil, errors.New("unrecognized structure")
+	}
+	a := &Activity{Header: cleanText(header.Text())}
+
+	lines := splitLines(content.Nodes[0])
+	if len(lines) < 2 {
+		return nil, errors.New("missing title or time")
+	}
+	a.Title, a.TitleURL = lines[0].Text, lines[0].URL
+	for _, l := range lines[1 : len(lines)-1] {
+		a.Subtitles = append(a.Subtitles, ActivitySubtitle{l.Text, l.URL})
+	}
+	t, err := parseActivityTime(lines[len(lines)-1].Text, loc)
+	if err != nil {
+		return nil, err
+	}
+	a.Time = t
+
+	if caption.Length() == 0 {
+		return a, nil
+	}
+	var section string
+	for _, l := range splitLines(caption.Nodes[0]) {
+		if l.Section != "" {
+			section = l.Section
+			if l.Text == "" {
+				continue
+			}
+		}
+		switch section {
+		case "Products:":
+			a.Products = append(a.Products, l.Text)
+		case "Details:":
+			a.Details = append(a.Details, ActivityDetail{l.Text})
+		case "Locations:":
+			a.LocationInfos = append(a.LocationInfos, LocationInfo{Name: l.Text, URL: l.URL})
+		default:
+			return nil, fmt.Errorf("unrecognized caption section %q", section)
+		}
+	}
+	return a, nil
+}
+
+func parseActivityTime(s string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation(activityTimeLayout, s, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// An abbreviation that is not in loc is given a fabricated zero
+	// offset, which is only correct for UTC.
+	if zone, _ := t.Zone(); t.Location() != loc && zone != "UTC" && zone != "GMT" {
+		return time.Time{}, fmt.Errorf("timezone %q not in %s", zone, loc)
+	}
+	return t.UTC(), nil
+}
+
+// activityLine is a line of text in an HTML activity cell, delimited by
+// <br>.
+type activityLine struct {
+	Section string // bold heading, e.g. "Products:"
+	Text    string
+	URL     string // first link
+}
+
+func splitLines(n *html.Node) []activityLine {
+	var lines []activityLine
+	var l activityLine
+	var b strings.Builder
+	flush := func() {
+		l.Text = cleanText(b.String())
+		if l.Text != "" || l.Section != "" {
+			lines = append(lines, l)
+		}
+		l = activityLine{}
+		b.Reset()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.TextNode:
+			b.WriteString(c.Data)
+		case c.Type == html.ElementNode && c.Data == "br":
+			flush()
+		case c.Type == html.ElementNode && c.Data == "b":
+			l.Section = cleanText(goquery.NewDocumentFromNode(c).Text())
+		case c.Type == html.ElementNode && c.Data == "a":
+			if l.URL == "" {
+				for _, attr := range c.Attr {
+					if attr.Key == "href" {
+						l.URL = attr.Val
+					}
+				}
+			}
+			b.WriteString(goquery.NewDocumentFromNode(c).Text())
+		default:
+			b.WriteString(goquery.NewDocumentFromNode(c).Text())
+		}
+	}
+	flush()
+	return lines
+}
+
+// cleanText replaces the non-breaking, narrow non-breaking, and em
+// spaces, which are used for layout, and trims spaces.
+func cleanText(s string) string {
+	s = strings.NewReplacer("\u00a0", " ", "\u202f", " ", "\u2003", " ").Replace(s)
+	return strings.TrimSpace(s)
+}
+
+// activityVerbs are the title prefixes of activity with a URL that can
+// be converted to visits.
+var activityVerbs = []string{"Visited ", "Searched for ", "Watched "}
+
+// Visit converts the activity to a visit and reports whether it is a
+// visit to a URL, such as a visited page, search, or watched video.
+// Google redirect URLs are resolved to their targets. The transition is
+// not recorded, so link is assumed, and the title omits the verb.
+func (a *Activity) Visit() (*Visit, bool) {
+	if a.TitleURL == "" {
+		return nil, false
+	}
+	for _, verb := range activityVerbs {
+		if strings.HasPrefix(a.Title, verb) {
+			return &Visit{
+				PageTransition: PageTransition(chrome.TransitionLink),
+				Title:          strings.TrimPrefix(a.Title, verb),
+				URL:            unwrapGoogleURL(a.TitleURL),
+				Time:           timefmt.UnixMicro{Time: a.Time},
+			}, true
+		}
+	}
+	return nil, false
+}
+
+// unwrapGoogleURL resolves a redirect through https://www.google.com/url,
+// which My Activity uses for visited pages, to its target.
+func unwrapGoogleURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host != "www.google.com" || u.Path != "/url" {
+		return rawURL
+	}
+	if q := u.Query().Get("q"); q != "" {
+		return q
+	}
+	return rawURL
+}
+
+// MyActivity contains activity from My Activity in a Takeout export.
+type MyActivity struct {
+	ExportTime time.Time
+	Chrome     []Activity // My Activity/Chrome
+	Search     []Activity // My Activity/Search
+}
+
+// ParseMyActivity parses Chrome and Search activity from My Activity in
+// a Takeout export. HTML times are interpreted in loc, as by
+// ParseActivityHTML.
+func ParseMyActivity(filename string, loc *time.Location) (*MyActivity, error) {
+	ex, err := NewExport(filename)
+	if err != nil {
+		return nil, err
+	}
+	res, err := ex.Parse(&Options{Location: loc}, "My Activity")
+	if err != nil {
+		return nil, err
+	}
+	return res.Results["My Activity"].(*MyActivity), nil
+}
+
+// myActivityParser parses the files in Takeout/My Activity for Chrome
+// and Search.
+type myActivityParser struct {
+	data     *MyActivity
+	loc      *time.Location
+	activity activitySet
+}
+
+func (p *myActivityParser) Match(name string) bool {
+	switch path.Dir(name) {
+	case "Takeout/My Activity/Chrome", "Takeout/My Activity/Search":
+		return true
+	}
+	return false
+}
+
+func (p *myActivityParser) Parse(f archive.File) error {
+	base := path.Base(f.Name())
+	if base != "MyActivity.json" && base != "MyActivity.html" {
+		return errors.New("unknown file")
+	}
+	return p.activity.add(path.Base(path.Dir(f.Name())), f, p.loc)
+}
+
+func (p *myActivityParser) Result() interface{} {
+	p.data.Chrome = p.activity.get("Chrome")
+	p.data.Search = p.activity.get("Search")
+	return p.data
+}
+
+// activitySet collects activity by kind, such as by product, from
+// either JSON or HTML files. Takeout exports activity in both formats
+// when both are selected, so JSON is preferred and HTML is only used
+// for kinds without JSON, so that activity is not duplicated.
+type activitySet struct {
+	json map[string][]Activity
+	html map[string][]Activity
+}
+
+// add parses an activity file of the given kind.
+func (s *activitySet) add(kind string, f archive.File, loc *time.Location) error {
+	activity, err := parseActivityFile(f, loc)
+	if err != nil {
+		return err
+	}
+	m := &s.html
+	if path.Ext(f.Name()) == ".json" {
+		m = &s.json
+	}
+	if *m == nil {
+		*m = make(map[string][]Activity)
+	}
+	(*m)[kind] = append((*m)[kind], activity...)
+	return nil
+}
+
+// get returns the activity of a kind, preferring JSON.
+func (s *activitySet) get(kind string) []Activity {
+	if activity, ok := s.json[kind]; ok {
+		return activity
+	}
+	return s.html[kind]
+}
+
+// parseActivityFile parses activity in JSON or HTML, as determined by
+// the file extension.
+func parseActivityFile(f archive.File, loc *time.Location) ([]Activity, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	switch ext := path.Ext(f.Name()); ext {
+	case ".json":
+		return ParseActivity(r)
+	case ".html":
+		return ParseActivityHTML(r, loc)
+	default:
+		return nil, fmt.Errorf("takeout: activity file extension not json or html: %q", ext)
+	}
+}
+
+// Visits converts all activity with URLs to visits, as by
+// Activity.Visit.
+func Visits(activity []Activity) []Visit {
+	var visits []Visit
+	for i := range activity {
+		if v, ok := activity[i].Visit(); ok {
+			visits = append(visits, *v)
+		}
+	}
+	return visits
+}
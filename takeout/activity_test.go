@@ -0,0 +1,113 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrewarchi/browser/internal/golden"
+)
+
+var est = time.FixedZone("EST", -5*60*60)
+
+func TestParseMyActivity(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "takeout-20210201T000000Z-001.zip")
+	writeTestExport(t, filename, filepath.Join("testdata", "Takeout"))
+	data, err := ParseMyActivity(filename, est)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "MyActivity", data)
+	golden.Check(t, "MyActivityVisits", Visits(append(data.Chrome, data.Search...)))
+}
+
+func TestParseActivityBothFormats(t *testing.T) {
+	// Activity selected in both JSON and HTML is only parsed from JSON.
+	// The HTML files here differ from the JSON, so that duplicated or
+	// replaced activity would be detected.
+	root := filepath.Join(t.TempDir(), "Takeout")
+	copyTestFiles(t, filepath.Join("testdata", "Takeout"), root)
+	for _, f := range []struct{ src, dst string }{
+		{"My Activity/Search/MyActivity.html", "My Activity/Chrome/MyActivity.html"},
+		{"YouTube and YouTube Music/history/search-history.html", "YouTube and YouTube Music/history/watch-history.html"},
+	} {
+		copyTestFiles(t, filepath.Join("testdata", "Takeout", f.src), filepath.Join(root, f.dst))
+	}
+	filename := filepath.Join(t.TempDir(), "takeout-20210201T000000Z-001.zip")
+	writeTestExport(t, filename, root)
+	want := filepath.Join(t.TempDir(), "takeout-20210201T000000Z-001.zip")
+	writeTestExport(t, want, filepath.Join("testdata", "Takeout"))
+
+	activity, err := ParseMyActivity(filename, est)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantActivity, err := ParseMyActivity(want, est)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(activity, wantActivity) {
+		t.Errorf("got %+v, want %+v", activity, wantActivity)
+	}
+	youTube, err := ParseYouTube(filename, est)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantYouTube, err := ParseYouTube(want, est)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(youTube, wantYouTube) {
+		t.Errorf("got %+v, want %+v", youTube, wantYouTube)
+	}
+}
+
+func TestParseActivityErrors(t *testing.T) {
+	for _, data := range []string{
+		`[{"header": "Chrome", "title": "Used Chrome", "time": "2021-02-01T00:00:00Z", "products": ["Chrome"], "unknown": 1}]`,
+		`[{"header": "Chrome", "title": "Used Chrome", "time": "Feb 1, 2021", "products": ["Chrome"]}]`,
+	} {
+		if _, err := ParseActivity(strings.NewReader(data)); err == nil {
+			t.Errorf("ParseActivity(%q): want error", data)
+		}
+	}
+}
+
+func TestParseActivityHTMLErrors(t *testing.T) {
+	const cell = `<div class="outer-cell"><div class="header-cell">Search</div>` +
+		`<div class="content-cell mdl-cell--6-col">Searched for <a href="https://www.google.com/search?q=x">x</a><br>%s</div>` +
+		`<div class="content-cell mdl-typography--caption">%s</div></div>`
+	for _, test := range []struct{ time, caption string }{
+		{"Jan 31, 2021, 7:00:00 PM PST", "<b>Products:</b><br>&emsp;Search"},
+		{"2021-01-31 19:00:00", "<b>Products:</b><br>&emsp;Search"},
+		{"Jan 31, 2021, 7:00:00 PM EST", "<b>Unknown:</b><br>&emsp;Search"},
+	} {
+		data := strings.Replace(strings.Replace(cell, "%s", test.time, 1), "%s", test.caption, 1)
+		if _, err := ParseActivityHTML(strings.NewReader(data), est); err == nil {
+			t.Errorf("ParseActivityHTML(%q): want error", data)
+		}
+	}
+	data := strings.Replace(strings.Replace(cell, "%s", "Feb 1, 2021, 12:00:00 AM UTC", 1), "%s", "", 1)
+	if _, err := ParseActivityHTML(strings.NewReader(data), est); err != nil {
+		t.Errorf("UTC time: %v", err)
+	}
+}
+
+func TestParseYouTube(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "takeout-20210201T000000Z-001.tgz")
+	writeTestExport(t, filename, filepath.Join("testdata", "Takeout"))
+	data, err := ParseYouTube(filename, est)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "YouTube", data)
+	golden.Check(t, "YouTubeVisits", YouTubeVisits(append(data.WatchHistory, data.SearchHistory...)))
+}
@@ -10,7 +10,9 @@ import (
 	"errors"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/andrewarchi/archive"
@@ -26,11 +28,15 @@ type Chrome struct {
 	// Autofill.json
 	Autofill        []AutofillProfile `json:"Autofill"` // appears in older exports
 	AutofillProfile []AutofillProfile `json:"Autofill Profile"`
+	CreditCards     []CreditCard      `json:"Credit Cards"`
 	// Bookmarks.html
 	Bookmarks []bookmark.BookmarkEntry
 	// BrowserHistory.json
 	BrowserHistory []Visit `json:"Browser History"`
-	// Dictionary.csv - TODO unknown structure
+	// Device Information.json
+	DeviceInfo []DeviceInfo `json:"Device Info"`
+	// Dictionary.csv
+	Dictionary []string
 	// Extensions.json
 	Extensions        []Extension        `json:"Extensions"`
 	ExtensionSettings []ExtensionSetting `json:"Extension Settings"`
@@ -42,6 +48,15 @@ type Chrome struct {
 	Preferences  []Preference           `json:"Preferences"`
 	Themes       []Theme                `json:"Themes"`
 	ManagedUsers []jsonutil.UnknownType `json:"Managed Users"`
+	// OS Settings.json (Chrome OS)
+	OSPreferences         []Preference `json:"OS Preferences"`
+	OSPriorityPreferences []Preference `json:"OS Priority Preferences"`
+	// ReadingList.html
+	ReadingList []bookmark.BookmarkEntry
+
+	// UnknownFiles lists the names of unrecognized files, when parsed
+	// with ChromeOptions.AllowUnknownFiles.
+	UnknownFiles []string `json:"-"`
 }
 
 type AutofillProfile struct {
@@ -70,13 +85,76 @@ type AutofillProfile struct {
 	UseDate                       timefmt.UnixSec `json:"use_date"`
 }
 
+// CreditCard is the metadata of a credit card saved for autofill. Card
+// numbers are not exported.
+type CreditCard struct {
+	GUID             *uuid.UUID      `json:"guid"` // "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"
+	NameOnCard       string          `json:"name_on_card"`
+	ExpirationMonth  int             `json:"expiration_month"`
+	ExpirationYear   int             `json:"expiration_year"`
+	Nickname         string          `json:"nickname"`
+	BillingAddressID string          `json:"billing_address_id"`
+	Origin           string          `json:"origin"`
+	UseCount         int             `json:"use_count"`
+	UseDate          timefmt.UnixSec `json:"use_date"`
+}
+
+// DeviceInfo is a device signed in to Chrome sync. The structure of
+// SharingFields is unknown, as it has only been seen empty, so a
+// populated value is rejected, rather than guessed from the sync
+// protocol.
+type DeviceInfo struct {
+	CacheGUID              string              `json:"cache_guid"`
+	ClientName             string              `json:"client_name"`
+	DeviceType             string              `json:"device_type"` // e.g. "TYPE_LINUX", "TYPE_PHONE"
+	SyncUserAgent          string              `json:"sync_user_agent"`
+	ChromeVersion          string              `json:"chrome_version"`
+	SigninScopedDeviceID   string              `json:"signin_scoped_device_id"`
+	Manufacturer           string              `json:"manufacturer,omitempty"`
+	Model                  string              `json:"model,omitempty"`
+	LastUpdatedTimestamp   timefmt.UnixMilli   `json:"last_updated_timestamp"`
+	PulseIntervalInMinutes int                 `json:"pulse_interval_in_minutes,omitempty"`
+	FeatureFields          *DeviceFeatures     `json:"feature_fields,omitempty"`
+	SharingFields          jsonutil.UnknownObj `json:"sharing_fields"` // only seen empty
+}
+
+// DeviceFeatures are the features enabled on a device.
+type DeviceFeatures struct {
+	SendTabToSelfReceivingEnabled bool `json:"send_tab_to_self_receiving_enabled"`
+}
+
 type Visit struct {
-	FaviconURL     string                `json:"favicon_url,omitempty"`
-	PageTransition chrome.PageTransition `json:"page_transition"`
-	Title          string                `json:"title"`
-	URL            string                `json:"url"`
-	ClientID       jsonutil.Base64       `json:"client_id"`
-	Time           timefmt.UnixMicro     `json:"time_usec"`
+	FaviconURL     string            `json:"favicon_url,omitempty"`
+	PageTransition PageTransition    `json:"page_transition"`
+	Title          string            `json:"title"`
+	URL            string            `json:"url"`
+	ClientID       jsonutil.Base64   `json:"client_id"`
+	Time           timefmt.UnixMicro `json:"time_usec"`
+}
+
+// PageTransition is a Chrome page transition that is formatted in
+// uppercase, as in Takeout (e.g. "LINK"). Takeout only exports the core
+// type, but qualifiers are formatted like chrome.PageTransition, as in
+// "LINK|FORWARD_BACK", so that they are not lost.
+type PageTransition chrome.PageTransition
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (typ PageTransition) MarshalText() ([]byte, error) {
+	return []byte(typ.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (typ *PageTransition) UnmarshalText(data []byte) error {
+	t, err := chrome.PageTransitionFromString(string(data))
+	if err != nil {
+		return err
+	}
+	*typ = PageTransition(t)
+	return nil
+}
+
+func (typ PageTransition) String() string {
+	return strings.ToUpper(chrome.PageTransition(typ).String())
 }
 
 type Extension struct {
@@ -115,7 +193,7 @@ type SearchEngine struct {
 	DeprecatedShowInDefaultList *bool          `json:"deprecated_show_in_default_list,omitempty"`
 	SyncGUID                    string         `json:"sync_guid"`
 	InputEncodings              string         `json:"input_encodings"` // e.g. "UTF-8"
-	AlternateUrls               []string       `json:"alternate_urls,omitempty"`
+	AlternateUrls               []string       `json:"alternate_urls"`
 	PrepopulateID               int64          `json:"prepopulate_id"`
 }
 
@@ -139,48 +217,87 @@ type Theme struct {
 	UseCustomTheme          bool `json:"use_custom_theme"`
 }
 
-// ParseChrome parses Chrome data in a Takeout export.
+// ChromeOptions controls the parsing of Chrome data in a Takeout
+// export.
+type ChromeOptions struct {
+	// AllowUnknownFiles records unrecognized files in
+	// Chrome.UnknownFiles, rather than failing, so that exports with
+	// files added to Takeout after this package can be parsed.
+	AllowUnknownFiles bool
+}
+
+// ParseChrome parses Chrome data in a Takeout export. Unrecognized files
+// are rejected.
 func ParseChrome(filename string) (*Chrome, error) {
+	return ParseChromeWithOptions(filename, ChromeOptions{})
+}
+
+// ParseChromeWithOptions parses Chrome data in a Takeout export with the
+// given options.
+func ParseChromeWithOptions(filename string, opts ChromeOptions) (*Chrome, error) {
 	ex, err := NewExport(filename)
 	if err != nil {
 		return nil, err
 	}
-	data := &Chrome{ExportTime: ex.Time}
-	err = ex.Walk(func(f archive.File) error {
-		dir, base := filepath.Split(f.Name())
-		if dir != "Takeout/Chrome/" {
-			return nil
+	res, err := ex.Parse(&Options{Chrome: opts}, "Chrome")
+	if err != nil {
+		return nil, err
+	}
+	return res.Results["Chrome"].(*Chrome), nil
+}
+
+// chromeParser parses the files in Takeout/Chrome.
+type chromeParser struct {
+	data *Chrome
+	opts ChromeOptions
+}
+
+func (p *chromeParser) Match(name string) bool {
+	return path.Dir(name) == "Takeout/Chrome"
+}
+
+func (p *chromeParser) Parse(f archive.File) error {
+	data := p.data
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	switch path.Base(f.Name()) {
+	case "Autofill.json", "BrowserHistory.json", "Device Information.json",
+		"Extensions.json", "OS Settings.json", "SearchEngines.json",
+		"SyncSettings.json":
+		return jsonutil.Decode(r, data)
+	case "Bookmarks.html":
+		b, err := bookmark.ParseHTML(r)
+		if err != nil {
+			return err
 		}
-		r, err := f.Open()
+		data.Bookmarks = b
+	case "ReadingList.html":
+		b, err := bookmark.ParseHTML(r)
 		if err != nil {
 			return err
 		}
-		defer r.Close()
-		switch base {
-		case "Autofill.json", "BrowserHistory.json", "Extensions.json",
-			"SearchEngines.json", "SyncSettings.json":
-			return jsonutil.Decode(r, data)
-		case "Bookmarks.html":
-			b, err := bookmark.ParseHTML(r)
-			if err != nil {
-				return err
-			}
-			data.Bookmarks = b
-		case "Dictionary.csv": // TODO unknown structure
-			if f.FileInfo().Size() != 0 {
-				return errors.New("dictionary structure unknown")
-			}
-		default:
-			return errors.New("unknown file")
+		data.ReadingList = b
+	case "Dictionary.csv":
+		words, err := ParseDictionary(r)
+		if err != nil {
+			return err
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
+		data.Dictionary = words
+	default:
+		if p.opts.AllowUnknownFiles {
+			data.UnknownFiles = append(data.UnknownFiles, f.Name())
+			return nil
+		}
+		return errors.New("unknown file")
 	}
-	return data, nil
+	return nil
 }
 
+func (p *chromeParser) Result() interface{} { return p.data }
+
 // ExtractChrome extracts Chrome data in a Takeout export to a
 // directory.
 func ExtractChrome(filename, dir string) error {
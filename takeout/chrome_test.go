@@ -0,0 +1,146 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/andrewarchi/browser/chrome"
+	"github.com/andrewarchi/browser/internal/golden"
+	"github.com/andrewarchi/browser/jsonutil"
+)
+
+func TestParseChrome(t *testing.T) {
+	for _, ext := range []string{"zip", "tgz"} {
+		filename := filepath.Join(t.TempDir(), "takeout-20210201T000000Z-001."+ext)
+		writeTestExport(t, filename, filepath.Join("testdata", "Takeout"))
+		data, err := ParseChrome(filename)
+		if err != nil {
+			t.Errorf("%s: %v", ext, err)
+			continue
+		}
+		golden.Check(t, "Chrome", data)
+	}
+}
+
+func TestChromeRoundTrip(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "takeout-20210201T000000Z-001.zip")
+	writeTestExport(t, filename, filepath.Join("testdata", "Takeout"))
+	data, err := ParseChrome(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]json.RawMessage
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join("testdata", "Takeout", "Chrome", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		want, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Compare only the keys from this file, since all files are
+		// decoded into the same struct.
+		var keys map[string]json.RawMessage
+		if err := json.Unmarshal(want, &keys); err != nil {
+			t.Fatal(err)
+		}
+		for k := range keys {
+			keys[k] = got[k]
+		}
+		sub, err := json.Marshal(keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		diffs, err := jsonutil.CompareJSON(want, sub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range diffs {
+			t.Errorf("%s: %s", file, d)
+		}
+	}
+}
+
+func TestSearchEngineAlternateURLs(t *testing.T) {
+	// Takeout always includes alternate_urls, even when empty, so
+	// omitempty would drop it.
+	data := []byte(`{"alternate_urls":[]}`)
+	var e SearchEngine
+	if err := json.Unmarshal(data, &e); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(&e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]json.RawMessage
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if string(got["alternate_urls"]) != "[]" {
+		t.Errorf("got alternate_urls %s, want []", got["alternate_urls"])
+	}
+}
+
+func TestPageTransitionQualifiers(t *testing.T) {
+	typ := PageTransition(chrome.TransitionLink | chrome.TransitionForwardBack | chrome.TransitionChainEnd)
+	text, err := typ.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "LINK|FORWARD_BACK|CHAIN_END"; string(text) != want {
+		t.Errorf("got %q, want %q", text, want)
+	}
+	var got PageTransition
+	if err := got.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if got != typ {
+		t.Errorf("got %#x, want %#x", uint32(got), uint32(typ))
+	}
+}
+
+func TestParseChromeUnknownFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Takeout")
+	chromeDir := filepath.Join(dir, "Chrome")
+	if err := os.MkdirAll(chromeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"BrowserHistory.json", "Future Feature.json"} {
+		if err := ioutil.WriteFile(filepath.Join(chromeDir, name), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filename := filepath.Join(t.TempDir(), "takeout-20210201T000000Z-001.zip")
+	writeTestExport(t, filename, dir)
+
+	if _, err := ParseChrome(filename); err == nil {
+		t.Error("ParseChrome: want error for unknown file")
+	}
+	data, err := ParseChromeWithOptions(filename, ChromeOptions{AllowUnknownFiles: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Takeout/Chrome/Future Feature.json"}; !reflect.DeepEqual(data.UnknownFiles, want) {
+		t.Errorf("got unknown files %q, want %q", data.UnknownFiles, want)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Dictionary.csv contains the words added to the custom spellcheck
+// dictionary, with one word per record. It is empty when no words have
+// been added.
+//
+// Chrome stores the dictionary in {profile}/Custom Dictionary.txt and
+// only accepts words of at most 99 bytes without whitespace:
+// https://source.chromium.org/chromium/chromium/src/+/master:chrome/browser/spellchecker/spellcheck_custom_dictionary.cc
+
+// maxDictionaryWordBytes is the maximum length of a word in the custom
+// dictionary, as in Chromium.
+const maxDictionaryWordBytes = 99
+
+// ParseDictionary parses the words in Dictionary.csv. The file may be
+// UTF-8 with or without a byte order mark or UTF-16 with a byte order
+// mark. Quotes within unquoted words, such as in 5'10", are taken
+// literally. Words that Chrome would not accept, such as words with
+// whitespace, are rejected and duplicate words are skipped, as in
+// Chrome.
+func ParseDictionary(r io.Reader) ([]string, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text, err := decodeText(b)
+	if err != nil {
+		return nil, fmt.Errorf("takeout: dictionary: %w", err)
+	}
+
+	cr := csv.NewReader(strings.NewReader(text))
+	cr.FieldsPerRecord = 1
+	cr.LazyQuotes = true
+	var words []string
+	seen := make(map[string]bool)
+	for n := 1; ; n++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return words, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("takeout: dictionary: %w", err)
+		}
+		word := record[0]
+		if err := checkDictionaryWord(word); err != nil {
+			return nil, fmt.Errorf("takeout: dictionary word %d: %w", n, err)
+		}
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		words = append(words, word)
+	}
+}
+
+func checkDictionaryWord(word string) error {
+	if word == "" {
+		return errors.New("empty word")
+	}
+	if len(word) > maxDictionaryWordBytes {
+		return fmt.Errorf("word longer than %d bytes: %q", maxDictionaryWordBytes, word)
+	}
+	if strings.IndexFunc(word, unicode.IsSpace) != -1 {
+		return fmt.Errorf("word contains whitespace: %q", word)
+	}
+	return nil
+}
+
+// decodeText decodes UTF-8 or UTF-16 text, as determined by the byte
+// order mark, and strips the byte order mark.
+func decodeText(b []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(b, []byte{0xEF, 0xBB, 0xBF}):
+		b = b[3:]
+	case bytes.HasPrefix(b, []byte{0xFF, 0xFE}):
+		return decodeUTF16(b[2:], false)
+	case bytes.HasPrefix(b, []byte{0xFE, 0xFF}):
+		return decodeUTF16(b[2:], true)
+	}
+	if !utf8.Valid(b) {
+		return "", errors.New("invalid UTF-8")
+	}
+	return string(b), nil
+}
+
+func decodeUTF16(b []byte, bigEndian bool) (string, error) {
+	if len(b)%2 != 0 {
+		return "", errors.New("odd length UTF-16")
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		if bigEndian {
+			u[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+		} else {
+			u[i] = uint16(b[2*i]) | uint16(b[2*i+1])<<8
+		}
+	}
+	runes := utf16.Decode(u)
+	for _, r := range runes {
+		if r == utf8.RuneError {
+			return "", errors.New("invalid UTF-16")
+		}
+	}
+	return string(runes), nil
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDictionary(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		words []string
+	}{
+		{"empty", "", nil},
+		{"utf-8", "colour\nnaïve\n", []string{"colour", "naïve"}},
+		{"utf-8 bom", "\xef\xbb\xbfcolour\r\nnaïve", []string{"colour", "naïve"}},
+		{"utf-16le bom", "\xff\xfec\x00o\x00l\x00o\x00u\x00r\x00\n\x00n\x00a\x00\xef\x00v\x00e\x00", []string{"colour", "naïve"}},
+		{"utf-16be bom", "\xfe\xff\x00c\x00o\x00l\x00o\x00u\x00r\x00\n\x00n\x00a\x00\xef\x00v\x00e", []string{"colour", "naïve"}},
+		{"quoted", "\"a,b\"\ndon't\n", []string{"a,b", "don't"}},
+		{"bare quote", "5'10\"\nsay\"\n", []string{"5'10\"", "say\""}},
+		{"duplicate", "colour\nnaïve\ncolour\n", []string{"colour", "naïve"}},
+	}
+	for _, test := range tests {
+		words, err := ParseDictionary(strings.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(words, test.words) {
+			t.Errorf("%s: got %q, want %q", test.name, words, test.words)
+		}
+	}
+}
+
+func TestParseDictionaryErrors(t *testing.T) {
+	for _, data := range []string{
+		"two words\n",
+		"a,b\n",
+		"\"\"\n",
+		strings.Repeat("a", 100) + "\n",
+		"\xffcolour\n",
+		"\xff\xfec\x00o",
+	} {
+		if words, err := ParseDictionary(strings.NewReader(data)); err == nil {
+			t.Errorf("ParseDictionary(%q) = %q, want error", data, words)
+		}
+	}
+}
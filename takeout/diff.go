@@ -0,0 +1,310 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/andrewarchi/browser/bookmark"
+)
+
+// ChromeDiff is the difference between the Chrome data of two Takeout
+// exports, from the older to the newer export. Exports are usually
+// downloaded periodically, so a diff is the delta to archive between
+// them.
+type ChromeDiff struct {
+	OldTime time.Time // time of the older export
+	NewTime time.Time // time of the newer export
+
+	AddedBookmarks   []BookmarkPath
+	RemovedBookmarks []BookmarkPath
+	// NewVisits are the visits in the newer export that are not in the
+	// older export.
+	NewVisits []Visit
+	// DeletedVisits are the visits in the older export that are missing
+	// from the newer export, but are no older than its earliest visit.
+	// Takeout only exports recent history, so visits older than that
+	// have expired, rather than been deleted.
+	DeletedVisits []Visit
+	Extensions    []ExtensionChange
+	SearchEngines []SearchEngineChange
+	Autofill      []AutofillChange
+	CreditCards   []CreditCardChange
+}
+
+// ChangeKind is the kind of change to an item between exports.
+type ChangeKind uint8
+
+// Values for ChangeKind:
+const (
+	_ ChangeKind = iota
+	Added
+	Removed
+	Modified
+)
+
+func (kind ChangeKind) String() string {
+	switch kind {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+	return fmt.Sprintf("change_kind(%d)", kind)
+}
+
+// BookmarkPath is a bookmark and the titles of the folders containing
+// it, from the root.
+type BookmarkPath struct {
+	Folders  []string
+	Bookmark bookmark.Bookmark
+}
+
+func (b BookmarkPath) String() string {
+	path := b.Bookmark.Title
+	if len(b.Folders) != 0 {
+		path = strings.Join(b.Folders, "/") + "/" + path
+	}
+	return path + " <" + b.Bookmark.URL + ">"
+}
+
+// ExtensionChange is a change to an extension, by ID. Old is nil when
+// added and New is nil when removed.
+type ExtensionChange struct {
+	Kind     ChangeKind
+	Old, New *Extension
+}
+
+// SearchEngineChange is a change to a search engine, by sync GUID. Old
+// is nil when added and New is nil when removed.
+type SearchEngineChange struct {
+	Kind     ChangeKind
+	Old, New *SearchEngine
+}
+
+// AutofillChange is a change to an autofill profile, by GUID. Old is
+// nil when added and New is nil when removed.
+type AutofillChange struct {
+	Kind     ChangeKind
+	Old, New *AutofillProfile
+}
+
+// CreditCardChange is a change to a credit card, by GUID. Old is nil
+// when added and New is nil when removed.
+type CreditCardChange struct {
+	Kind     ChangeKind
+	Old, New *CreditCard
+}
+
+// DiffChrome compares the Chrome data of an older and a newer export.
+// Items are identified as follows and any other difference is a
+// modification:
+//
+//   - Bookmarks by folder path, title, URL, and date added, so a
+//     bookmark that was renamed or moved is removed and added.
+//   - Visits by URL and time.
+//   - Extensions by ID.
+//   - Search engines by sync GUID, or keyword when it has none.
+//   - Autofill profiles, from both Autofill and AutofillProfile, and
+//     credit cards by GUID, or all fields when they have none.
+//
+// Changes are ordered as in the newer export, followed by removals in
+// the order of the older export. The exports are ordered by export
+// time, so they may be given in either order.
+func DiffChrome(older, newer *Chrome) *ChromeDiff {
+	if older.ExportTime.After(newer.ExportTime) {
+		older, newer = newer, older
+	}
+	d := &ChromeDiff{OldTime: older.ExportTime, NewTime: newer.ExportTime}
+
+	oldBookmarks, newBookmarks := flattenBookmarks(older.Bookmarks), flattenBookmarks(newer.Bookmarks)
+	diffKeyed(len(oldBookmarks), len(newBookmarks),
+		func(i int) string { return bookmarkKey(&oldBookmarks[i]) },
+		func(j int) string { return bookmarkKey(&newBookmarks[j]) },
+		func(i, j int) bool { return true },
+		func(kind ChangeKind, i, j int) {
+			if kind == Added {
+				d.AddedBookmarks = append(d.AddedBookmarks, newBookmarks[j])
+			} else {
+				d.RemovedBookmarks = append(d.RemovedBookmarks, oldBookmarks[i])
+			}
+		})
+
+	var earliest time.Time
+	for _, v := range newer.BrowserHistory {
+		if earliest.IsZero() || v.Time.Before(earliest) {
+			earliest = v.Time.Time
+		}
+	}
+	diffKeyed(len(older.BrowserHistory), len(newer.BrowserHistory),
+		func(i int) string { return visitKey(&older.BrowserHistory[i]) },
+		func(j int) string { return visitKey(&newer.BrowserHistory[j]) },
+		func(i, j int) bool { return true },
+		func(kind ChangeKind, i, j int) {
+			if kind == Added {
+				d.NewVisits = append(d.NewVisits, newer.BrowserHistory[j])
+			} else if v := older.BrowserHistory[i]; !v.Time.Before(earliest) {
+				d.DeletedVisits = append(d.DeletedVisits, v)
+			}
+		})
+
+	diffKeyed(len(older.Extensions), len(newer.Extensions),
+		func(i int) string { return older.Extensions[i].ID },
+		func(j int) string { return newer.Extensions[j].ID },
+		func(i, j int) bool { return reflect.DeepEqual(older.Extensions[i], newer.Extensions[j]) },
+		func(kind ChangeKind, i, j int) {
+			c := ExtensionChange{Kind: kind}
+			if i != -1 {
+				c.Old = &older.Extensions[i]
+			}
+			if j != -1 {
+				c.New = &newer.Extensions[j]
+			}
+			d.Extensions = append(d.Extensions, c)
+		})
+
+	diffKeyed(len(older.SearchEngines), len(newer.SearchEngines),
+		func(i int) string { return searchEngineKey(&older.SearchEngines[i]) },
+		func(j int) string { return searchEngineKey(&newer.SearchEngines[j]) },
+		func(i, j int) bool { return reflect.DeepEqual(older.SearchEngines[i], newer.SearchEngines[j]) },
+		func(kind ChangeKind, i, j int) {
+			c := SearchEngineChange{Kind: kind}
+			if i != -1 {
+				c.Old = &older.SearchEngines[i]
+			}
+			if j != -1 {
+				c.New = &newer.SearchEngines[j]
+			}
+			d.SearchEngines = append(d.SearchEngines, c)
+		})
+
+	oldProfiles := append(append([]AutofillProfile(nil), older.Autofill...), older.AutofillProfile...)
+	newProfiles := append(append([]AutofillProfile(nil), newer.Autofill...), newer.AutofillProfile...)
+	diffKeyed(len(oldProfiles), len(newProfiles),
+		func(i int) string { return autofillKey(&oldProfiles[i]) },
+		func(j int) string { return autofillKey(&newProfiles[j]) },
+		func(i, j int) bool { return reflect.DeepEqual(oldProfiles[i], newProfiles[j]) },
+		func(kind ChangeKind, i, j int) {
+			c := AutofillChange{Kind: kind}
+			if i != -1 {
+				c.Old = &oldProfiles[i]
+			}
+			if j != -1 {
+				c.New = &newProfiles[j]
+			}
+			d.Autofill = append(d.Autofill, c)
+		})
+
+	diffKeyed(len(older.CreditCards), len(newer.CreditCards),
+		func(i int) string { return creditCardKey(&older.CreditCards[i]) },
+		func(j int) string { return creditCardKey(&newer.CreditCards[j]) },
+		func(i, j int) bool { return reflect.DeepEqual(older.CreditCards[i], newer.CreditCards[j]) },
+		func(kind ChangeKind, i, j int) {
+			c := CreditCardChange{Kind: kind}
+			if i != -1 {
+				c.Old = &older.CreditCards[i]
+			}
+			if j != -1 {
+				c.New = &newer.CreditCards[j]
+			}
+			d.CreditCards = append(d.CreditCards, c)
+		})
+	return d
+}
+
+// Empty reports whether the exports have no differences.
+func (d *ChromeDiff) Empty() bool {
+	return len(d.AddedBookmarks) == 0 && len(d.RemovedBookmarks) == 0 &&
+		len(d.NewVisits) == 0 && len(d.DeletedVisits) == 0 &&
+		len(d.Extensions) == 0 && len(d.SearchEngines) == 0 &&
+		len(d.Autofill) == 0 && len(d.CreditCards) == 0
+}
+
+// diffKeyed compares lists of n old and m new items that are
+// identified by key. Items with the same key are paired in order. The
+// change function is called for each added, removed, or modified item
+// with the index of the old item, or -1 when added, and of the new
+// item, or -1 when removed.
+func diffKeyed(n, m int, oldKey, newKey func(i int) string, equal func(i, j int) bool, change func(kind ChangeKind, i, j int)) {
+	olds := make(map[string][]int, n)
+	for i := 0; i < n; i++ {
+		k := oldKey(i)
+		olds[k] = append(olds[k], i)
+	}
+	paired := make([]bool, n)
+	for j := 0; j < m; j++ {
+		k := newKey(j)
+		is := olds[k]
+		if len(is) == 0 {
+			change(Added, -1, j)
+			continue
+		}
+		i := is[0]
+		olds[k] = is[1:]
+		paired[i] = true
+		if !equal(i, j) {
+			change(Modified, i, j)
+		}
+	}
+	for i := 0; i < n; i++ {
+		if !paired[i] {
+			change(Removed, i, -1)
+		}
+	}
+}
+
+// flattenBookmarks lists the bookmarks in a tree in order.
+func flattenBookmarks(entries []bookmark.BookmarkEntry) []BookmarkPath {
+	var list []BookmarkPath
+	var walk func(entries []bookmark.BookmarkEntry, folders []string)
+	walk = func(entries []bookmark.BookmarkEntry, folders []string) {
+		for _, e := range entries {
+			switch e := e.(type) {
+			case *bookmark.Bookmark:
+				list = append(list, BookmarkPath{folders, *e})
+			case *bookmark.BookmarkFolder:
+				walk(e.Entries, append(folders[:len(folders):len(folders)], e.Title))
+			}
+		}
+	}
+	walk(entries, nil)
+	return list
+}
+
+func bookmarkKey(b *BookmarkPath) string {
+	return fmt.Sprintf("%q %q %q %d", b.Folders, b.Bookmark.Title, b.Bookmark.URL, b.Bookmark.AddDate.UnixNano())
+}
+
+func visitKey(v *Visit) string {
+	return fmt.Sprintf("%q %d", v.URL, v.Time.UnixNano())
+}
+
+func searchEngineKey(e *SearchEngine) string {
+	if e.SyncGUID != "" {
+		return "guid:" + e.SyncGUID
+	}
+	return "keyword:" + e.Keyword
+}
+
+func autofillKey(p *AutofillProfile) string {
+	if p.GUID != nil {
+		return p.GUID.String()
+	}
+	return fmt.Sprintf("%+v", *p)
+}
+
+func creditCardKey(c *CreditCard) string {
+	if c.GUID != nil {
+		return c.GUID.String()
+	}
+	return fmt.Sprintf("%+v", *c)
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andrewarchi/browser/bookmark"
+	"github.com/andrewarchi/browser/jsonutil/timefmt"
+	"github.com/andrewarchi/browser/jsonutil/uuid"
+)
+
+func TestDiffChrome(t *testing.T) {
+	date := func(day int) time.Time { return time.Date(2021, 1, day, 0, 0, 0, 0, time.UTC) }
+	visit := func(url string, day int) Visit {
+		return Visit{URL: url, Time: timefmt.UnixMicro{Time: date(day)}}
+	}
+	guid := func(s string) *uuid.UUID {
+		id, err := uuid.Decode([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	home := guid("00000000-0000-0000-0000-000000000001")
+	work := guid("00000000-0000-0000-0000-000000000002")
+	card := guid("00000000-0000-0000-0000-000000000003")
+
+	older := &Chrome{
+		ExportTime: date(10),
+		Bookmarks: []bookmark.BookmarkEntry{
+			&bookmark.BookmarkFolder{Title: "Bookmarks bar", Entries: []bookmark.BookmarkEntry{
+				&bookmark.Bookmark{Title: "Example Domain", URL: "https://example.com/", AddDate: date(1)},
+				&bookmark.Bookmark{Title: "Article", URL: "https://example.org/article", AddDate: date(2)},
+			}},
+		},
+		BrowserHistory: []Visit{
+			visit("https://example.com/", 1),
+			visit("https://example.com/", 3),
+			visit("https://example.org/article", 4),
+		},
+		Extensions: []Extension{
+			{ID: "abcdefghijklmnopabcdefghijklmnop", Version: "1.0", Enabled: true},
+			{ID: "ponmlkjihgfedcbaponmlkjihgfedcba", Version: "2.0", Enabled: true},
+		},
+		SearchEngines:   []SearchEngine{{Keyword: "example.com", SyncGUID: "a"}},
+		Autofill:        []AutofillProfile{{GUID: home, AddressHomeCity: "Springfield"}},
+		AutofillProfile: []AutofillProfile{{GUID: work, CompanyName: "Example"}},
+		CreditCards:     []CreditCard{{GUID: card, ExpirationYear: 2021}},
+	}
+	newer := &Chrome{
+		ExportTime: date(20),
+		Bookmarks: []bookmark.BookmarkEntry{
+			&bookmark.BookmarkFolder{Title: "Bookmarks bar", Entries: []bookmark.BookmarkEntry{
+				&bookmark.Bookmark{Title: "Example Domain", URL: "https://example.com/", AddDate: date(1)},
+				&bookmark.BookmarkFolder{Title: "Reading", Entries: []bookmark.BookmarkEntry{
+					&bookmark.Bookmark{Title: "Article", URL: "https://example.org/article", AddDate: date(2)},
+				}},
+			}},
+			&bookmark.Bookmark{Title: "Example Net", URL: "https://example.net/", AddDate: date(12)},
+		},
+		BrowserHistory: []Visit{
+			visit("https://example.com/", 3),
+			visit("https://example.net/", 12),
+		},
+		Extensions: []Extension{
+			{ID: "ponmlkjihgfedcbaponmlkjihgfedcba", Version: "2.1", Enabled: true},
+			{ID: "qrstuvwxyzabcdefqrstuvwxyzabcdef", Version: "1.0", Enabled: true},
+		},
+		SearchEngines:   []SearchEngine{{Keyword: "example.com", SyncGUID: "a"}, {Keyword: "example.net", SyncGUID: "b"}},
+		AutofillProfile: []AutofillProfile{{GUID: home, AddressHomeCity: "Shelbyville"}, {GUID: work, CompanyName: "Example"}},
+		CreditCards:     []CreditCard{{GUID: card, ExpirationYear: 2021}},
+	}
+
+	d := DiffChrome(older, newer)
+	want := &ChromeDiff{
+		OldTime: date(10),
+		NewTime: date(20),
+		AddedBookmarks: []BookmarkPath{
+			{[]string{"Bookmarks bar", "Reading"}, bookmark.Bookmark{Title: "Article", URL: "https://example.org/article", AddDate: date(2)}},
+			{nil, bookmark.Bookmark{Title: "Example Net", URL: "https://example.net/", AddDate: date(12)}},
+		},
+		RemovedBookmarks: []BookmarkPath{
+			{[]string{"Bookmarks bar"}, bookmark.Bookmark{Title: "Article", URL: "https://example.org/article", AddDate: date(2)}},
+		},
+		NewVisits:     []Visit{visit("https://example.net/", 12)},
+		DeletedVisits: []Visit{visit("https://example.org/article", 4)},
+		Extensions: []ExtensionChange{
+			{Modified, &older.Extensions[1], &newer.Extensions[0]},
+			{Added, nil, &newer.Extensions[1]},
+			{Removed, &older.Extensions[0], nil},
+		},
+		SearchEngines: []SearchEngineChange{
+			{Added, nil, &newer.SearchEngines[1]},
+		},
+		Autofill: []AutofillChange{
+			{Modified, &older.Autofill[0], &newer.AutofillProfile[0]},
+		},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("got %+v, want %+v", d, want)
+	}
+	if d.Empty() {
+		t.Error("got empty diff")
+	}
+	if got, want := d.AddedBookmarks[0].String(), "Bookmarks bar/Reading/Article <https://example.org/article>"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if d := DiffChrome(newer, older); !reflect.DeepEqual(d, want) {
+		t.Errorf("swapped: got %+v, want %+v", d, want)
+	}
+}
+
+func TestDiffChromeSame(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "takeout-20210201T000000Z-001.zip")
+	writeTestExport(t, filename, filepath.Join("testdata", "Takeout"))
+	older, err := ParseChrome(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newer, err := ParseChrome(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := DiffChrome(older, newer); !d.Empty() {
+		t.Errorf("got %+v, want empty diff", d)
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/andrewarchi/archive"
@@ -18,44 +20,110 @@ import (
 
 // Export contains the paths to each part in a Takeout export and the
 // time of export. Zip exports are significantly faster to traverse than
-// tgz and should be preferred.
+// tgz and should be preferred. Parse and Index traverse parts
+// concurrently, which offsets the cost of tgz for multi-part
+// exports.
 type Export struct {
 	Time      time.Time // time of export from filename
 	Timestamp string    // raw timestamp
-	Parts     []string  // paths to multi-part archives
+	Parts     []string  // paths to multi-part archives, ordered by number
+	Numbers   []int     // part numbers, starting at 1
+	Missing   []int     // part numbers missing before the last part found
 }
 
-var exportPattern = regexp.MustCompile(`^takeout-(\d{8}T\d{6}Z)-(\d{3})\.(tgz|zip)$`)
+// exportPattern matches export filenames. A suffix like " (1)", which
+// browsers add when a file is downloaded more than once, is permitted.
+var exportPattern = regexp.MustCompile(`^takeout-(\d{8}T\d{6}Z)-(\d{3})( \(\d+\))?\.(tgz|zip)$`)
 
-// NewExport opens a Takeout export, given the path to the first archive
-// in a multi-part export.
+// NewExport opens a Takeout export, given the path to any archive in a
+// multi-part export. The other parts are found in the same directory
+// and may be a mix of zip and tgz archives. When a part was downloaded
+// more than once, the copy without a suffix is preferred, then the copy
+// with the lowest suffix.
+//
+// Parts missing from the sequence are listed in Missing, but parts
+// after the last part found cannot be detected, as the number of parts
+// is not in the filenames.
 func NewExport(filename string) (*Export, error) {
 	dir, base := filepath.Split(filename)
 	match := exportPattern.FindStringSubmatch(base)
-	if len(match) != 4 {
+	if len(match) != 5 {
 		return nil, fmt.Errorf("takeout: path is not an export: %q", base)
 	}
-	timestamp, seq, ext := match[1], match[2], match[3]
-	if seq != "001" {
-		return nil, fmt.Errorf("takeout: archive not first in sequence: %q", seq)
-	}
+	timestamp := match[1]
 	t, err := time.Parse("20060102T150405Z", timestamp)
 	if err != nil {
 		return nil, fmt.Errorf("takeout: export timestamp: %w", err)
 	}
-	glob := filepath.Join(dir, "takeout-"+timestamp+"-???."+ext)
-	parts, err := filepath.Glob(glob)
+	glob := filepath.Join(dir, "takeout-"+timestamp+"-*")
+	matches, err := filepath.Glob(glob)
 	if err != nil {
 		return nil, err
 	}
-	return &Export{t, timestamp, parts}, nil
+
+	// Select one copy of each part.
+	parts := make(map[int]string)
+	for _, path := range matches {
+		m := exportPattern.FindStringSubmatch(filepath.Base(path))
+		if len(m) != 5 || m[1] != timestamp {
+			continue
+		}
+		n, err := strconv.Atoi(m[2])
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("takeout: illegal part number: %q", m[2])
+		}
+		if prev, ok := parts[n]; !ok || partPreferred(path, prev) {
+			parts[n] = path
+		}
+	}
+	ex := &Export{Time: t, Timestamp: timestamp}
+	for n := range parts {
+		ex.Numbers = append(ex.Numbers, n)
+	}
+	sort.Ints(ex.Numbers)
+	for i, n := range ex.Numbers {
+		ex.Parts = append(ex.Parts, parts[n])
+		prev := 0
+		if i > 0 {
+			prev = ex.Numbers[i-1]
+		}
+		for m := prev + 1; m < n; m++ {
+			ex.Missing = append(ex.Missing, m)
+		}
+	}
+	return ex, nil
+}
+
+// partPreferred reports whether path is preferred over prev as the copy
+// of a part: no download suffix, then the lowest suffix, then zip.
+func partPreferred(path, prev string) bool {
+	m1 := exportPattern.FindStringSubmatch(filepath.Base(path))
+	m2 := exportPattern.FindStringSubmatch(filepath.Base(prev))
+	if m1[3] != m2[3] {
+		if m1[3] == "" || m2[3] == "" {
+			return m1[3] == ""
+		}
+		return len(m1[3]) < len(m2[3]) || len(m1[3]) == len(m2[3]) && m1[3] < m2[3]
+	}
+	return m1[4] == "zip" && m2[4] != "zip"
 }
 
 // Walk traverses a Takeout export and executes the given walk function
 // on each file.
 func (ex *Export) Walk(walk archive.WalkFunc) error {
-	for _, part := range ex.Parts {
-		if err := archive.Walk(part, walk); err != nil {
+	return ex.walkParts(func(_ int, f archive.File) error {
+		return walk(f)
+	})
+}
+
+// walkParts traverses a Takeout export like Walk, with the index of the
+// part containing each file.
+func (ex *Export) walkParts(walk func(part int, f archive.File) error) error {
+	for i, part := range ex.Parts {
+		err := archive.Walk(part, func(f archive.File) error {
+			return walk(i, f)
+		})
+		if err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,128 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTestExport writes the files in dir to a zip or tgz archive,
+// depending on the extension of filename, with paths relative to the
+// parent of dir.
+func writeTestExport(t *testing.T, filename, dir string) {
+	t.Helper()
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var create func(name string, size int64) (io.Writer, error)
+	var close func() error
+	switch filepath.Ext(filename) {
+	case ".zip":
+		zw := zip.NewWriter(f)
+		create = func(name string, size int64) (io.Writer, error) { return zw.Create(name) }
+		close = zw.Close
+	case ".tgz":
+		gw := gzip.NewWriter(f)
+		tw := tar.NewWriter(gw)
+		create = func(name string, size int64) (io.Writer, error) {
+			h := &tar.Header{Name: name, Mode: 0644, Size: size, Typeflag: tar.TypeReg}
+			return tw, tw.WriteHeader(h)
+		}
+		close = func() error {
+			if err := tw.Close(); err != nil {
+				return err
+			}
+			return gw.Close()
+		}
+	default:
+		t.Fatalf("unsupported archive: %s", filename)
+	}
+
+	root := filepath.Dir(dir)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		w, err := create(strings.ReplaceAll(rel, string(filepath.Separator), "/"), info.Size())
+		if err != nil {
+			return err
+		}
+		r, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		_, err = io.Copy(w, r)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewExport(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"takeout-20210201T000000Z-001 (1).zip",
+		"takeout-20210201T000000Z-001 (2).zip",
+		"takeout-20210201T000000Z-002.tgz",
+		"takeout-20210201T000000Z-002 (1).zip",
+		"takeout-20210201T000000Z-004.zip",
+		"takeout-20210201T000000Z-004.tgz",
+		"takeout-20210202T000000Z-003.zip",
+		"takeout-20210201T000000Z-003.zip.crdownload",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ex, err := NewExport(filepath.Join(dir, "takeout-20210201T000000Z-004.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []string
+	for _, part := range ex.Parts {
+		parts = append(parts, filepath.Base(part))
+	}
+	wantParts := []string{
+		"takeout-20210201T000000Z-001 (1).zip",
+		"takeout-20210201T000000Z-002.tgz",
+		"takeout-20210201T000000Z-004.zip",
+	}
+	if !reflect.DeepEqual(parts, wantParts) {
+		t.Errorf("got parts %q, want %q", parts, wantParts)
+	}
+	if want := []int{1, 2, 4}; !reflect.DeepEqual(ex.Numbers, want) {
+		t.Errorf("got numbers %v, want %v", ex.Numbers, want)
+	}
+	if want := []int{3}; !reflect.DeepEqual(ex.Missing, want) {
+		t.Errorf("got missing %v, want %v", ex.Missing, want)
+	}
+
+	if _, err := NewExport(filepath.Join(dir, "takeout-20210201T000000Z-003.zip.crdownload")); err == nil {
+		t.Error("partial download: want error")
+	}
+}
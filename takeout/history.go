@@ -0,0 +1,106 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// HistoryReader streams visits from BrowserHistory.json, so that large
+// histories do not need to be held in memory. Like the other parsers,
+// unknown fields are rejected.
+type HistoryReader struct {
+	d     *json.Decoder
+	state int // 0: before array, 1: in array, 2: done
+	index int // index of next visit
+}
+
+// NewHistoryReader returns a HistoryReader that reads from r.
+func NewHistoryReader(r io.Reader) *HistoryReader {
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+	return &HistoryReader{d: d}
+}
+
+// Read reads a single visit. It returns io.EOF after the last visit.
+func (r *HistoryReader) Read() (*Visit, error) {
+	v, err := r.read()
+	if err != nil && err != io.EOF {
+		r.state = 2
+		return nil, fmt.Errorf("takeout: browser history visit %d: %w", r.index, err)
+	}
+	return v, err
+}
+
+func (r *HistoryReader) read() (*Visit, error) {
+	switch r.state {
+	case 0:
+		if err := r.expectDelim('{'); err != nil {
+			return nil, err
+		}
+		key, err := r.d.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key != "Browser History" {
+			return nil, fmt.Errorf("unexpected key %v", key)
+		}
+		if err := r.expectDelim('['); err != nil {
+			return nil, err
+		}
+		r.state = 1
+		return r.read()
+	case 1:
+		if !r.d.More() {
+			r.state = 2
+			return nil, r.end()
+		}
+		var v Visit
+		if err := r.d.Decode(&v); err != nil {
+			return nil, err
+		}
+		r.index++
+		return &v, nil
+	default:
+		return nil, io.EOF
+	}
+}
+
+// end checks that the array and object are closed and that no data
+// follows.
+func (r *HistoryReader) end() error {
+	if err := r.expectDelim(']'); err != nil {
+		return err
+	}
+	if r.d.More() {
+		return errors.New("unexpected key after visits")
+	}
+	if err := r.expectDelim('}'); err != nil {
+		return err
+	}
+	if _, err := r.d.Token(); err != io.EOF {
+		return errors.New("invalid trailing data")
+	}
+	return io.EOF
+}
+
+func (r *HistoryReader) expectDelim(delim json.Delim) error {
+	tok, err := r.d.Token()
+	if err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andrewarchi/browser/jsonutil"
+)
+
+func TestHistoryReader(t *testing.T) {
+	filename := filepath.Join("testdata", "Takeout", "Chrome", "BrowserHistory.json")
+	var want Chrome
+	if err := jsonutil.DecodeFile(filename, &want); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := readHistory(NewHistoryReader(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want.BrowserHistory) {
+		t.Errorf("got %v, want %v", got, want.BrowserHistory)
+	}
+}
+
+func TestHistoryReaderErrors(t *testing.T) {
+	tests := []string{
+		`{"Browser History": [{"url": "https://example.com/", "unknown": 1}]}`,
+		`{"Browser History": []} {}`,
+		`{"Browser History": [], "Extensions": []}`,
+		`{"Extensions": []}`,
+		`{"Browser History": [`,
+		`[]`,
+	}
+	for _, test := range tests {
+		if _, err := readHistory(NewHistoryReader(strings.NewReader(test))); err == nil {
+			t.Errorf("%s: expected error", test)
+		}
+	}
+	if visits, err := readHistory(NewHistoryReader(strings.NewReader(`{"Browser History": []}`))); err != nil || len(visits) != 0 {
+		t.Errorf("empty history: got %v, %v", visits, err)
+	}
+}
+
+func readHistory(r *HistoryReader) ([]Visit, error) {
+	var visits []Visit
+	for {
+		v, err := r.Read()
+		if err == io.EOF {
+			return visits, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		visits = append(visits, *v)
+	}
+}
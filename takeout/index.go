@@ -0,0 +1,116 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/andrewarchi/archive"
+)
+
+// Index lists the files in a Takeout export by service and the parts
+// that contain them.
+type Index struct {
+	Parts    []string       // paths to the archives in the export
+	Services []ServiceIndex // sorted by name
+	Files    []FileIndex    // files directly in Takeout/, like archive_browser.html
+}
+
+// ServiceIndex lists the files of a service, which is a directory in
+// Takeout/, such as "Chrome" or "My Activity".
+type ServiceIndex struct {
+	Name  string
+	Files []FileIndex
+	Size  int64 // total uncompressed size
+	Parts []int // indexes in Index.Parts of the parts containing files
+}
+
+// FileIndex is a file in an export.
+type FileIndex struct {
+	Name string // path in the archive, e.g. "Takeout/Chrome/Bookmarks.html"
+	Size int64  // uncompressed size
+	Part int    // index in Index.Parts of the part containing the file
+}
+
+// Index lists the files in the export. Parts are traversed
+// concurrently.
+func (ex *Export) Index() (*Index, error) {
+	return ex.parse(context.Background(), 0, nil)
+}
+
+// Service returns the index of the named service.
+func (idx *Index) Service(name string) (*ServiceIndex, bool) {
+	i := sort.Search(len(idx.Services), func(i int) bool { return idx.Services[i].Name >= name })
+	if i < len(idx.Services) && idx.Services[i].Name == name {
+		return &idx.Services[i], true
+	}
+	return nil, false
+}
+
+// PartFiles returns the files contained in the part with the given
+// index.
+func (idx *Index) PartFiles(part int) []FileIndex {
+	var files []FileIndex
+	for _, f := range idx.Files {
+		if f.Part == part {
+			files = append(files, f)
+		}
+	}
+	for _, s := range idx.Services {
+		for _, f := range s.Files {
+			if f.Part == part {
+				files = append(files, f)
+			}
+		}
+	}
+	return files
+}
+
+type indexBuilder struct {
+	idx      *Index
+	services map[string]*ServiceIndex
+}
+
+func newIndexBuilder(ex *Export) *indexBuilder {
+	return &indexBuilder{
+		idx:      &Index{Parts: ex.Parts},
+		services: make(map[string]*ServiceIndex),
+	}
+}
+
+func (ib *indexBuilder) add(part int, f archive.File) {
+	fi := FileIndex{f.Name(), f.FileInfo().Size(), part}
+	rel := strings.TrimPrefix(f.Name(), "Takeout/")
+	i := strings.IndexByte(rel, '/')
+	if rel == f.Name() || i == -1 {
+		ib.idx.Files = append(ib.idx.Files, fi)
+		return
+	}
+	name := rel[:i]
+	s, ok := ib.services[name]
+	if !ok {
+		s = &ServiceIndex{Name: name}
+		ib.services[name] = s
+	}
+	s.Files = append(s.Files, fi)
+	s.Size += fi.Size
+	if len(s.Parts) == 0 || s.Parts[len(s.Parts)-1] != part {
+		s.Parts = append(s.Parts, part)
+	}
+}
+
+func (ib *indexBuilder) index() *Index {
+	for _, s := range ib.services {
+		ib.idx.Services = append(ib.idx.Services, *s)
+	}
+	sort.Slice(ib.idx.Services, func(i, j int) bool {
+		return ib.idx.Services[i].Name < ib.idx.Services[j].Name
+	})
+	return ib.idx
+}
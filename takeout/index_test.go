@@ -0,0 +1,80 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/andrewarchi/browser/internal/golden"
+)
+
+func TestIndex(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "takeout-20210201T000000Z-001.zip")
+	writeTestExport(t, filename, filepath.Join("testdata", "Takeout"))
+	ex, err := NewExport(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := ex.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range idx.Services {
+		names = append(names, s.Name)
+		if !reflect.DeepEqual(s.Parts, []int{0}) {
+			t.Errorf("%s: got parts %v, want [0]", s.Name, s.Parts)
+		}
+	}
+	if want := []string{"Chrome", "My Activity", "YouTube and YouTube Music"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got services %q, want %q", names, want)
+	}
+	s, ok := idx.Service("Chrome")
+	if !ok {
+		t.Fatal("Chrome not indexed")
+	}
+	var size int64
+	for _, f := range s.Files {
+		size += f.Size
+	}
+	if len(s.Files) != 10 || s.Size != size || size == 0 {
+		t.Errorf("Chrome: got %d files and size %d, want 10 files and size %d", len(s.Files), s.Size, size)
+	}
+	if n := len(idx.PartFiles(0)); n != 15 {
+		t.Errorf("got %d files in part 0, want 15", n)
+	}
+}
+
+func TestParseServices(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "takeout-20210201T000000Z-001.tgz")
+	writeTestExport(t, filename, filepath.Join("testdata", "Takeout"))
+	ex, err := NewExport(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := ex.Parse(&Options{Location: est})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range Services() {
+		if res.Results[s.Name] == nil {
+			t.Errorf("%s: no result", s.Name)
+		}
+	}
+	golden.Check(t, "Chrome", res.Results["Chrome"])
+	golden.Check(t, "MyActivity", res.Results["My Activity"])
+	golden.Check(t, "YouTube", res.Results["YouTube"])
+	if len(res.Index.Services) != 3 {
+		t.Errorf("got %d indexed services, want 3", len(res.Index.Services))
+	}
+
+	if _, err := ex.Parse(nil, "Location History"); err == nil {
+		t.Error("unregistered service: want error")
+	}
+}
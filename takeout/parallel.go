@@ -0,0 +1,192 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/andrewarchi/archive"
+)
+
+// WalkParallel traverses the parts of a Takeout export concurrently,
+// with at most workers parts at once, or GOMAXPROCS parts when workers
+// is 0. The walk function is called sequentially for the files within a
+// part, but concurrently for files in different parts. When ctx is
+// canceled or walk returns an error, traversal stops and the first
+// error is returned.
+func (ex *Export) WalkParallel(ctx context.Context, workers int, walk func(part int, f archive.File) error) error {
+	return ex.walkParallel(ctx, workers, walk, nil)
+}
+
+// walkParallel traverses a Takeout export like WalkParallel and, when
+// done is non-nil, calls it once for every part, after the part has
+// been traversed or has been skipped due to an error. A traversed part
+// holds its worker until done returns, so done can block to limit how
+// many parts are in progress.
+func (ex *Export) walkParallel(ctx context.Context, workers int, walk func(part int, f archive.File) error, done func(part int, err error)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, workers)
+	for i, part := range ex.Parts {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			if done != nil {
+				for j := i; j < len(ex.Parts); j++ {
+					done(j, err)
+				}
+			}
+			break
+		}
+		wg.Add(1)
+		go func(i int, part string) {
+			defer wg.Done()
+			err := archive.Walk(part, func(f archive.File) error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				return walk(i, f)
+			})
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+			if done != nil {
+				done(i, err)
+			}
+			<-sem
+		}(i, part)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// parse indexes the export and gives each file to the first parser that
+// matches it. Parts are traversed concurrently, but files are indexed
+// and parsed in archive order, so the results are the same as with a
+// sequential traversal. Matched files are read into memory while
+// traversing, so that a part can be traversed before the parts
+// preceding it have been parsed, but a part holds its worker until it
+// has been parsed, so at most workers parts are buffered at once. Files
+// that are only indexed, which are the bulk of most exports, are not
+// read.
+func (ex *Export) parse(ctx context.Context, workers int, parsers []Parser) (*Index, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	files := make([][]*bufferedFile, len(ex.Parts))
+	errs := make([]error, len(ex.Parts))
+	done := make([]chan struct{}, len(ex.Parts))
+	parsed := make([]chan struct{}, len(ex.Parts))
+	for i := range done {
+		done[i] = make(chan struct{})
+		parsed[i] = make(chan struct{})
+	}
+	walkErr := make(chan error, 1)
+	go func() {
+		walkErr <- ex.walkParallel(ctx, workers, func(part int, f archive.File) error {
+			if f.FileInfo().IsDir() {
+				return nil
+			}
+			bf := &bufferedFile{name: f.Name(), info: f.FileInfo()}
+			if bf.parser = matchParser(parsers, f.Name()); bf.parser != nil {
+				r, err := f.Open()
+				if err != nil {
+					return err
+				}
+				bf.data, err = ioutil.ReadAll(r)
+				r.Close()
+				if err != nil {
+					return err
+				}
+			}
+			files[part] = append(files[part], bf)
+			return nil
+		}, func(part int, err error) {
+			errs[part] = err
+			close(done[part])
+			select {
+			case <-parsed[part]:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	ib := newIndexBuilder(ex)
+	for i := range ex.Parts {
+		<-done[i]
+		if errs[i] != nil {
+			cancel()
+			break
+		}
+		for _, f := range files[i] {
+			ib.add(i, f)
+			if f.parser == nil {
+				continue
+			}
+			if err := f.parser.Parse(f); err != nil {
+				cancel()
+				<-walkErr
+				return nil, fmt.Errorf("takeout: parse %s:%s: %w", ex.Parts[i], f.name, err)
+			}
+		}
+		files[i] = nil
+		close(parsed[i])
+	}
+	if err := <-walkErr; err != nil {
+		return nil, err
+	}
+	return ib.index(), nil
+}
+
+// matchParser returns the first parser that matches the named file, or
+// nil if none match.
+func matchParser(parsers []Parser, name string) Parser {
+	for _, p := range parsers {
+		if p.Match(name) {
+			return p
+		}
+	}
+	return nil
+}
+
+// bufferedFile is a file traversed in an archive. Its contents are read
+// into memory only when a parser matches it.
+type bufferedFile struct {
+	name   string
+	info   os.FileInfo
+	data   []byte
+	parser Parser
+}
+
+func (f *bufferedFile) Name() string          { return f.name }
+func (f *bufferedFile) FileInfo() os.FileInfo { return f.info }
+
+func (f *bufferedFile) Open() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(f.data)), nil
+}
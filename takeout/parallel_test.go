@@ -0,0 +1,167 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewarchi/archive"
+	"github.com/andrewarchi/browser/internal/golden"
+)
+
+// writeMultiPartExport writes the test export split into parts of
+// mixed formats, with the files of Chrome spread across parts, and
+// returns the path to the first part.
+func writeMultiPartExport(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	parts := []struct {
+		name  string
+		files []string
+	}{
+		{"takeout-20210201T000000Z-001.tgz", []string{"archive_browser.html", "Chrome/Autofill.json", "Chrome/Bookmarks.html", "My Activity"}},
+		{"takeout-20210201T000000Z-002.zip", []string{"Chrome/BrowserHistory.json", "Chrome/Device Information.json", "Chrome/Dictionary.csv"}},
+		{"takeout-20210201T000000Z-003.tgz", []string{"Chrome/Extensions.json", "Chrome/OS Settings.json", "Chrome/ReadingList.html"}},
+		{"takeout-20210201T000000Z-004.zip", []string{"Chrome/SearchEngines.json", "Chrome/SyncSettings.json", "YouTube and YouTube Music"}},
+	}
+	for _, p := range parts {
+		root := filepath.Join(t.TempDir(), "Takeout")
+		for _, name := range p.files {
+			copyTestFiles(t, filepath.Join("testdata", "Takeout", name), filepath.Join(root, name))
+		}
+		writeTestExport(t, filepath.Join(dir, p.name), root)
+	}
+	return filepath.Join(dir, parts[0].name)
+}
+
+// copyTestFiles copies a file or directory tree.
+func copyTestFiles(t *testing.T, src, dst string) {
+	t.Helper()
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, b, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseParallel(t *testing.T) {
+	ex, err := NewExport(writeMultiPartExport(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ex.Parts) != 4 {
+		t.Fatalf("got %d parts, want 4", len(ex.Parts))
+	}
+	want, err := ex.Parse(&Options{Location: est, Workers: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "Chrome", want.Results["Chrome"])
+	golden.Check(t, "MyActivity", want.Results["My Activity"])
+	golden.Check(t, "YouTube", want.Results["YouTube"])
+	if s, ok := want.Index.Service("Chrome"); !ok || !reflect.DeepEqual(s.Parts, []int{0, 1, 2, 3}) {
+		t.Errorf("Chrome: got index %+v, want parts [0 1 2 3]", s)
+	}
+
+	for _, workers := range []int{0, 2, 4, 8} {
+		for i := 0; i < 5; i++ {
+			got, err := ex.Parse(&Options{Location: est, Workers: workers})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%d workers: parallel result differs from sequential", workers)
+			}
+		}
+	}
+
+	idx, err := ex.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(idx, want.Index) {
+		t.Errorf("got index %+v, want %+v", idx, want.Index)
+	}
+}
+
+func TestParseCanceled(t *testing.T) {
+	ex, err := NewExport(writeMultiPartExport(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ex.ParseContext(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+
+	// An error in one part stops the traversal of the others.
+	errStop := errors.New("stop")
+	err = ex.WalkParallel(context.Background(), 2, func(part int, f archive.File) error {
+		if part == 1 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("got error %v, want %v", err, errStop)
+	}
+}
+
+func TestWalkParallelDone(t *testing.T) {
+	ex, err := NewExport(writeMultiPartExport(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A part holds its worker until done returns, so the next part is
+	// not traversed while done blocks.
+	var mu sync.Mutex
+	started := make(map[int]bool)
+	err = ex.walkParallel(context.Background(), 1, func(part int, f archive.File) error {
+		mu.Lock()
+		started[part] = true
+		mu.Unlock()
+		return nil
+	}, func(part int, err error) {
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		if started[part+1] {
+			t.Errorf("part %d traversed before done returned for part %d", part+1, part)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/andrewarchi/archive"
+)
+
+// Service parses the files of a Google service in a Takeout export.
+// Services are registered by name, so that any combination can be
+// parsed in a single pass over the archives with Export.Parse.
+type Service struct {
+	Name string // e.g. "Chrome"
+	// New returns a parser for an export.
+	New func(ex *Export, opts *Options) Parser
+}
+
+// Parser parses the files of a service in an export. Parse is called
+// for one file at a time.
+type Parser interface {
+	// Match reports whether the parser handles the named file. It may
+	// be called concurrently.
+	Match(name string) bool
+	// Parse parses a file that matched.
+	Parse(f archive.File) error
+	// Result returns the parsed data, such as *Chrome.
+	Result() interface{}
+}
+
+// Options configures the parsers of services.
+type Options struct {
+	Chrome ChromeOptions
+	// Location is the timezone of the export, which is needed to parse
+	// times in HTML activity files. UTC is used when nil.
+	Location *time.Location
+	// Workers is the maximum number of parts traversed concurrently.
+	// GOMAXPROCS is used when 0. The files matched by parsers are
+	// buffered in memory for at most Workers parts at once.
+	Workers int
+}
+
+func (opts *Options) location() *time.Location {
+	if opts == nil || opts.Location == nil {
+		return time.UTC
+	}
+	return opts.Location
+}
+
+var (
+	servicesMu sync.RWMutex
+	services   = make(map[string]*Service)
+)
+
+// RegisterService registers a service. It panics if a service with the
+// same name is already registered.
+func RegisterService(s *Service) {
+	servicesMu.Lock()
+	defer servicesMu.Unlock()
+	if _, dup := services[s.Name]; dup {
+		panic("takeout: RegisterService called twice for " + s.Name)
+	}
+	services[s.Name] = s
+}
+
+// LookupService returns the registered service with the given name.
+func LookupService(name string) (*Service, bool) {
+	servicesMu.RLock()
+	defer servicesMu.RUnlock()
+	s, ok := services[name]
+	return s, ok
+}
+
+// Services returns the registered services, sorted by name.
+func Services() []*Service {
+	servicesMu.RLock()
+	defer servicesMu.RUnlock()
+	list := make([]*Service, 0, len(services))
+	for _, s := range services {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func init() {
+	RegisterService(&Service{"Chrome", func(ex *Export, opts *Options) Parser {
+		var chromeOpts ChromeOptions
+		if opts != nil {
+			chromeOpts = opts.Chrome
+		}
+		return &chromeParser{&Chrome{ExportTime: ex.Time}, chromeOpts}
+	}})
+	RegisterService(&Service{"My Activity", func(ex *Export, opts *Options) Parser {
+		return &myActivityParser{data: &MyActivity{ExportTime: ex.Time}, loc: opts.location()}
+	}})
+	RegisterService(&Service{"YouTube", func(ex *Export, opts *Options) Parser {
+		return &youTubeParser{data: &YouTube{ExportTime: ex.Time}, loc: opts.location()}
+	}})
+}
+
+// ParseResult is the result of parsing an export.
+type ParseResult struct {
+	Index   *Index
+	Results map[string]interface{} // by service name
+}
+
+// Parse indexes the export and parses the named services, or all
+// registered services when none are named, in a single pass over the
+// archives, like ParseContext.
+func (ex *Export) Parse(opts *Options, names ...string) (*ParseResult, error) {
+	return ex.ParseContext(context.Background(), opts, names...)
+}
+
+// ParseContext indexes the export and parses the named services, or all
+// registered services when none are named, in a single pass over the
+// archives. Each file is given to the first service, in the order
+// named, that matches it. Files that no service matches are only
+// indexed. Parts are traversed concurrently, as configured by
+// Options.Workers, but files are parsed in the same order as when
+// traversed sequentially, so results are deterministic.
+func (ex *Export) ParseContext(ctx context.Context, opts *Options, names ...string) (*ParseResult, error) {
+	var svcs []*Service
+	if len(names) == 0 {
+		svcs = Services()
+	} else {
+		for _, name := range names {
+			s, ok := LookupService(name)
+			if !ok {
+				return nil, fmt.Errorf("takeout: unknown service: %q", name)
+			}
+			svcs = append(svcs, s)
+		}
+	}
+	parsers := make([]Parser, len(svcs))
+	for i, s := range svcs {
+		parsers[i] = s.New(ex, opts)
+	}
+	var workers int
+	if opts != nil {
+		workers = opts.Workers
+	}
+	idx, err := ex.parse(ctx, workers, parsers)
+	if err != nil {
+		return nil, err
+	}
+	res := &ParseResult{Index: idx, Results: make(map[string]interface{}, len(svcs))}
+	for i, s := range svcs {
+		res.Results[s.Name] = parsers[i].Result()
+	}
+	return res, nil
+}
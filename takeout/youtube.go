@@ -0,0 +1,130 @@
+// Copyright (c) 2021 Andrew Archibald
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package takeout
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/andrewarchi/archive"
+)
+
+// YouTube history is exported in the My Activity format in
+// Takeout/YouTube and YouTube Music/history/ as watch-history and
+// search-history, each as JSON or HTML. Videos watched on YouTube Music
+// have the header "YouTube Music".
+
+// YouTube contains watch and search history from YouTube and YouTube
+// Music in a Takeout export.
+type YouTube struct {
+	ExportTime    time.Time
+	WatchHistory  []Activity // watch-history.{json|html}
+	SearchHistory []Activity // search-history.{json|html}
+}
+
+// ParseYouTube parses YouTube watch and search history in a Takeout
+// export. HTML times are interpreted in loc, as by ParseActivityHTML.
+func ParseYouTube(filename string, loc *time.Location) (*YouTube, error) {
+	ex, err := NewExport(filename)
+	if err != nil {
+		return nil, err
+	}
+	res, err := ex.Parse(&Options{Location: loc}, "YouTube")
+	if err != nil {
+		return nil, err
+	}
+	return res.Results["YouTube"].(*YouTube), nil
+}
+
+// youTubeParser parses the files in
+// Takeout/YouTube and YouTube Music/history.
+type youTubeParser struct {
+	data     *YouTube
+	loc      *time.Location
+	activity activitySet
+}
+
+func (p *youTubeParser) Match(name string) bool {
+	return path.Dir(name) == "Takeout/YouTube and YouTube Music/history"
+}
+
+func (p *youTubeParser) Parse(f archive.File) error {
+	switch base := path.Base(f.Name()); base {
+	case "watch-history.json", "watch-history.html",
+		"search-history.json", "search-history.html":
+		return p.activity.add(strings.TrimSuffix(base, path.Ext(base)), f, p.loc)
+	}
+	return errors.New("unknown file")
+}
+
+func (p *youTubeParser) Result() interface{} {
+	p.data.WatchHistory = p.activity.get("watch-history")
+	p.data.SearchHistory = p.activity.get("search-history")
+	return p.data
+}
+
+// YouTubeVisit is a watched video or a search on YouTube.
+type YouTubeVisit struct {
+	Visit
+	VideoID     string // for watched videos
+	ChannelName string // for watched videos, when available
+	ChannelURL  string
+	Query       string // for searches
+	Music       bool   // on YouTube Music
+	Ad          bool   // watched from Google Ads
+}
+
+// YouTubeVisit converts YouTube activity to a visit with video and
+// channel metadata and reports whether it is a watched video or search
+// with a URL. Videos that have since been removed have no URL.
+func (a *Activity) YouTubeVisit() (*YouTubeVisit, bool) {
+	if a.Header != "YouTube" && a.Header != "YouTube Music" {
+		return nil, false
+	}
+	v, ok := a.Visit()
+	if !ok {
+		return nil, false
+	}
+	yv := &YouTubeVisit{Visit: *v, Music: a.Header == "YouTube Music"}
+	u, err := url.Parse(v.URL)
+	if err != nil {
+		return nil, false
+	}
+	switch {
+	case strings.HasPrefix(a.Title, "Watched "):
+		yv.VideoID = u.Query().Get("v")
+		if len(a.Subtitles) != 0 {
+			yv.ChannelName = a.Subtitles[0].Name
+			yv.ChannelURL = a.Subtitles[0].URL
+		}
+	case strings.HasPrefix(a.Title, "Searched for "):
+		yv.Query = u.Query().Get("search_query")
+	default:
+		return nil, false
+	}
+	for _, d := range a.Details {
+		if d.Name == "From Google Ads" {
+			yv.Ad = true
+		}
+	}
+	return yv, true
+}
+
+// YouTubeVisits converts all YouTube activity with URLs to visits, as
+// by Activity.YouTubeVisit.
+func YouTubeVisits(activity []Activity) []YouTubeVisit {
+	var visits []YouTubeVisit
+	for i := range activity {
+		if v, ok := activity[i].YouTubeVisit(); ok {
+			visits = append(visits, *v)
+		}
+	}
+	return visits
+}